@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"MinecraftCrawler/internal/storage"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// openCurrentStore abre la base de datos para los comandos de consulta. No
+// migra ni crea nada: si falta el fichero o el esquema está atrasado, falla
+// pidiendo 'db migrate'.
+func openCurrentStore(ctx context.Context, dsn string) (storage.Store, error) {
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		path := strings.TrimPrefix(dsn, "sqlite://")
+		if path != ":memory:" && !strings.HasPrefix(path, "file:") {
+			if _, err := os.Stat(path); err != nil {
+				return nil, fmt.Errorf("error al abrir la base de datos: %w", err)
+			}
+		}
+	}
+
+	store, err := storage.Open(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir la base de datos: %w", err)
+	}
+	current, latest, err := store.SchemaVersion(ctx)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("error leyendo la versión del esquema: %w", err)
+	}
+	if current < latest {
+		store.Close()
+		return nil, fmt.Errorf("el esquema está en la versión %d de %d; ejecuta 'mccrawler db migrate' antes", current, latest)
+	}
+	return store, nil
+}
+
+var DBCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Gestiona el esquema de la base de datos",
+}
+
+var DBMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Aplica las migraciones pendientes",
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := storage.Open(dbPath)
+		if err != nil {
+			fmt.Printf("Error al abrir la base de datos: %v\n", err)
+			os.Exit(1)
+		}
+		defer store.Close()
+
+		applied, err := store.Migrate(cmd.Context())
+		if err != nil {
+			fmt.Printf("Error aplicando migraciones: %v\n", err)
+			os.Exit(1)
+		}
+
+		version, _, _ := store.SchemaVersion(cmd.Context())
+		fmt.Printf("[*] %d migraciones aplicadas. Versión del esquema: %d\n", applied, version)
+	},
+}
+
+var DBVersionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Muestra la versión del esquema",
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := storage.Open(dbPath)
+		if err != nil {
+			fmt.Printf("Error al abrir la base de datos: %v\n", err)
+			os.Exit(1)
+		}
+		defer store.Close()
+
+		current, latest, err := store.SchemaVersion(cmd.Context())
+		if err != nil {
+			fmt.Printf("Error leyendo la versión: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Versión actual: %d\nÚltima versión: %d\n", current, latest)
+		if current < latest {
+			fmt.Println("Ejecuta 'mccrawler db migrate' para actualizar.")
+		}
+	},
+}
+
+func init() {
+	DBCmd.AddCommand(DBMigrateCmd)
+	DBCmd.AddCommand(DBVersionCmd)
+	rootCmd.AddCommand(DBCmd)
+}
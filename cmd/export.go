@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"MinecraftCrawler/internal/export"
+	"MinecraftCrawler/internal/protocol"
+	"MinecraftCrawler/internal/storage"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat     string
+	exportFile       string
+	exportVersion    string
+	exportMinPlayers int
+	exportWhitelist  bool
+	exportSince      time.Duration
+	exportLogin      string
+	exportSoftware   string
+	exportProxy      bool
+)
+
+var ExportCmd = &cobra.Command{
+	Use:          "export",
+	Short:        "Exporta los servidores a JSON, NDJSON o CSV",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter := storage.Filter{
+			Version:    exportVersion,
+			MinPlayers: exportMinPlayers,
+			Software:   exportSoftware,
+		}
+		if cmd.Flags().Changed("whitelist") {
+			filter.Whitelist = &exportWhitelist
+		}
+		if cmd.Flags().Changed("proxy") {
+			filter.Proxy = &exportProxy
+		}
+		if exportLogin != "" {
+			if !validLoginOutcome(exportLogin) {
+				return fmt.Errorf("--login no válido: %q", exportLogin)
+			}
+			filter.Login = protocol.LoginOutcome(exportLogin)
+		}
+		if exportSince > 0 {
+			filter.SeenSince = time.Now().Add(-exportSince)
+		}
+
+		store, err := openCurrentStore(cmd.Context(), dbPath)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		var out io.Writer = os.Stdout
+		if exportFile != "" && exportFile != "-" {
+			f, err := os.Create(exportFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		buffered := bufio.NewWriter(out)
+		defer buffered.Flush()
+
+		w, err := export.NewWriter(exportFormat, buffered)
+		if err != nil {
+			return err
+		}
+
+		count := 0
+		err = store.QueryServers(cmd.Context(), filter, func(rec *storage.ServerRecord) error {
+			count++
+			return w.Write(rec)
+		})
+		if err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "[*] %d servidores exportados\n", count)
+		return nil
+	},
+}
+
+func validLoginOutcome(s string) bool {
+	for _, o := range protocol.LoginOutcomes {
+		if string(o) == s {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	ExportCmd.Flags().StringVarP(&exportFormat, "format", "f", export.FormatJSON, "Formato de salida: json, ndjson o csv")
+	ExportCmd.Flags().StringVar(&exportFile, "file", "-", "Fichero de salida (- para stdout)")
+	ExportCmd.Flags().StringVar(&exportVersion, "version", "", "Solo versiones que contengan este texto")
+	ExportCmd.Flags().IntVar(&exportMinPlayers, "min-players", 0, "Mínimo de jugadores conectados")
+	ExportCmd.Flags().BoolVar(&exportWhitelist, "whitelist", false, "Filtra por estado de whitelist (true/false)")
+	ExportCmd.Flags().StringVar(&exportSoftware, "software", "", "Solo esta implementación (Paper, Fabric, Velocity...)")
+	ExportCmd.Flags().BoolVar(&exportProxy, "proxy", false, "Filtra proxies (BungeeCord, Velocity...) o backends (true/false)")
+	ExportCmd.Flags().StringVar(&exportLogin, "login", "", "Resultado del login: online, offline, whitelisted, banned, modded_required, proxy_rejected o kicked")
+	ExportCmd.Flags().DurationVar(&exportSince, "since", 0, "Solo servidores vistos en esta ventana (ej: 24h)")
+	rootCmd.AddCommand(ExportCmd)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"MinecraftCrawler/internal/storage"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var playersLimit int
+
+// playerPresence resume los avistamientos de un jugador en un servidor
+type playerPresence struct {
+	server    string
+	name      string
+	uuid      string
+	source    string
+	firstSeen time.Time
+	lastSeen  time.Time
+	count     int
+}
+
+var PlayersCmd = &cobra.Command{
+	Use:          "players <uuid|nombre>",
+	Short:        "Muestra en qué servidores se ha visto a un jugador",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter := storage.SightingFilter{Limit: playersLimit}
+		if storage.IsUUID(args[0]) {
+			filter.UUID = args[0]
+		} else {
+			filter.Name = args[0]
+		}
+
+		store, err := openCurrentStore(cmd.Context(), dbPath)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		// Los avistamientos llegan del más reciente al más antiguo; se agrupan
+		// por servidor y jugador
+		var order []string
+		presence := make(map[string]*playerPresence)
+		servers := make(map[string]bool)
+		total := 0
+		err = store.QuerySightings(cmd.Context(), filter, func(s *storage.Sighting) error {
+			total++
+			server := fmt.Sprintf("%s:%d", s.IP, s.Port)
+			servers[server] = true
+			key := server + "|" + s.UUID + "|" + s.Name
+			p, ok := presence[key]
+			if !ok {
+				p = &playerPresence{server: server, name: s.Name, uuid: s.UUID, source: s.Source, lastSeen: s.Timestamp}
+				presence[key] = p
+				order = append(order, key)
+			}
+			p.firstSeen = s.Timestamp
+			p.count++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if total == 0 {
+			fmt.Fprintf(os.Stderr, "[*] No hay avistamientos de %s\n", args[0])
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVIDOR\tJUGADOR\tUUID\tORIGEN\tPRIMERA VEZ\tÚLTIMA VEZ\tVECES")
+		for _, key := range order {
+			p := presence[key]
+			uuid := p.uuid
+			if uuid == "" {
+				uuid = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\n", p.server, p.name, uuid, p.source,
+				p.firstSeen.UTC().Format(time.RFC3339), p.lastSeen.UTC().Format(time.RFC3339), p.count)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "[*] %d avistamientos en %d servidores\n", total, len(servers))
+		return nil
+	},
+}
+
+func init() {
+	PlayersCmd.Flags().IntVar(&playersLimit, "limit", 0, "Máximo de avistamientos a leer (0 = sin límite)")
+	rootCmd.AddCommand(PlayersCmd)
+}
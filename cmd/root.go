@@ -20,5 +20,5 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&dbPath, "output", "o", "results.db", "Archivo SQLite de salida")
+	rootCmd.PersistentFlags().StringVarP(&dbPath, "output", "o", "results.db", "Archivo SQLite o DSN postgres:// de salida")
 }
\ No newline at end of file
@@ -1,15 +1,21 @@
 package cmd
 
 import (
+	"MinecraftCrawler/internal/pipeline"
 	"MinecraftCrawler/internal/protocol"
 	"MinecraftCrawler/internal/scanner"
 	"MinecraftCrawler/internal/storage"
+	"MinecraftCrawler/internal/vhost"
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"sync"
+	"os/signal"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -18,17 +24,189 @@ import (
 var (
 	ipRange     string
 	rate        string
-	port        int
+	portSpec    string
+	ports       []int
 	workers     int
 	verbose     bool
 	excludeFile string
+	edition     string
+	flushEvery  time.Duration
+	discovery   string
+	targetsFile string
+	targetsFmt  string
+	connConc    int
+	kickRules   string
+	vhostSrcs   []string
+	vhostList   string
+	dnsServer   string
 )
 
+const (
+	discoveryMasscan = "masscan"
+	discoveryConnect = "connect"
+	discoveryList    = "list"
+)
+
+// newDiscoverer construye el backend de descubrimiento elegido con --discovery.
+// El cierre devuelto libera el fichero de objetivos si lo hay.
+func newDiscoverer() (scanner.Discoverer, func(), error) {
+	noop := func() {}
+	switch discovery {
+	case discoveryMasscan:
+		return &scanner.Masscan{
+			Range:       ipRange,
+			Rate:        rate,
+			Ports:       ports,
+			ExcludeFile: excludeFile,
+			Bedrock:     edition == protocol.EditionBedrock,
+		}, noop, nil
+	case discoveryConnect:
+		if edition == protocol.EditionBedrock {
+			return nil, noop, fmt.Errorf("el descubrimiento connect solo sirve para TCP; usa masscan o list con bedrock")
+		}
+		pps, err := strconv.Atoi(rate)
+		if err != nil {
+			return nil, noop, fmt.Errorf("rate inválido: %s", rate)
+		}
+		var targets []string
+		for _, t := range strings.Split(ipRange, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				targets = append(targets, t)
+			}
+		}
+		if len(targets) == 0 {
+			return nil, noop, fmt.Errorf("--range es obligatorio con --discovery connect (ej: 1.1.1.0/24,2.2.2.2)")
+		}
+		var excludes []string
+		if excludeFile != "" {
+			if excludes, err = scanner.LoadExcludeFile(excludeFile); err != nil {
+				return nil, noop, err
+			}
+		}
+		return &scanner.ConnectScanner{
+			Targets:     targets,
+			Ports:       ports,
+			Concurrency: connConc,
+			Rate:        pps,
+			Timeout:     2 * time.Second,
+			Exclude:     excludes,
+		}, noop, nil
+	case discoveryList:
+		if targetsFile == "" || targetsFile == "-" {
+			d, err := scanner.NewTargetSource(targetsFmt, os.Stdin, ports)
+			return d, noop, err
+		}
+		f, err := os.Open(targetsFile)
+		if err != nil {
+			return nil, noop, err
+		}
+		d, err := scanner.NewTargetSource(targetsFmt, f, ports)
+		if err != nil {
+			f.Close()
+			return nil, noop, err
+		}
+		return d, func() { f.Close() }, nil
+	default:
+		return nil, noop, fmt.Errorf("descubrimiento no soportado: %s (usa masscan, connect o list)", discovery)
+	}
+}
+
+// loadKickRules antepone las reglas del fichero a las incluidas, de modo que
+// pueden corregir una categoría sin tener que copiar el resto.
+func loadKickRules(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rules, err := protocol.ParseKickRules(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	protocol.DefaultKickClassifier = protocol.NewKickClassifier(append(rules, protocol.BuiltinKickRules()...))
+	return nil
+}
+
+// newVhostFinder prepara el sondeo de virtual hosts con las fuentes de
+// --vhosts. Devuelve nil si no se pidió ninguna ni hay --vhost-list.
+func newVhostFinder() (*vhost.Finder, error) {
+	if len(vhostSrcs) == 0 && vhostList == "" {
+		return nil, nil
+	}
+	finder := &vhost.Finder{Timeout: 4 * time.Second}
+	for _, src := range vhostSrcs {
+		switch src {
+		case protocol.HostSourceReverseDNS:
+			finder.ReverseDNS = true
+		case protocol.HostSourceCertificate:
+			finder.CertPorts = []int{443}
+		case protocol.HostSourceSRV:
+			finder.SRV = true
+		default:
+			return nil, fmt.Errorf("fuente de virtual hosts no soportada: %s (usa ptr, cert o srv)", src)
+		}
+	}
+	return finder, nil
+}
+
+// loadVhostList resuelve los hostnames de --vhost-list. Puede tardar con listas
+// largas, así que se hace con el contexto que cancela Ctrl-C.
+func loadVhostList(ctx context.Context, finder *vhost.Finder) error {
+	f, err := os.Open(vhostList)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if finder.Hosts, err = vhost.LoadHostList(ctx, f, nil); err != nil {
+		return fmt.Errorf("%s: %w", vhostList, err)
+	}
+	return nil
+}
+
 var ScanCmd = &cobra.Command{
 	Use:   "scan",
 	Short: "Inicia el escaneo y análisis",
 	Run: func(cmd *cobra.Command, args []string) {
+		if edition != protocol.EditionJava && edition != protocol.EditionBedrock {
+			fmt.Printf("Edición no soportada: %s (usa java o bedrock)\n", edition)
+			return
+		}
+		if edition == protocol.EditionBedrock && !cmd.Flags().Changed("port") {
+			portSpec = strconv.Itoa(protocol.DefaultBedrockPort)
+		}
+		var err error
+		if ports, err = scanner.ParsePorts(portSpec); err != nil {
+			fmt.Println(err)
+			return
+		}
+		// Pasar --targets implica que no hay que descubrir nada
+		if cmd.Flags().Changed("targets") && !cmd.Flags().Changed("discovery") {
+			discovery = discoveryList
+		}
+		discoverer, closeTargets, err := newDiscoverer()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer closeTargets()
 
+		if kickRules != "" {
+			if err := loadKickRules(kickRules); err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+
+		// Los nombres de --targets y de --vhost-list se resuelven contra --dns
+		if dnsServer != "" {
+			scanner.DefaultResolver = scanner.NewResolver(dnsServer)
+		}
+		finder, err := newVhostFinder()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
 
 		// 1. Configurar Logger dual (Archivo + Consola)
 		logFile, err := os.OpenFile("crawler.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
@@ -42,71 +220,132 @@ var ScanCmd = &cobra.Command{
 		multiWriter := io.MultiWriter(os.Stdout, logFile)
 		log.SetOutput(multiWriter)
 
-		// 2. Inicializar DB
-		db, err := storage.NewDatabase(dbPath)
+		// Ctrl-C / SIGTERM cancelan el contexto: se detiene el descubrimiento, se vacía la cola
+		// y se espera a que se guarde el último lote. Una segunda señal fuerza la salida.
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigChan)
+		go func() {
+			select {
+			case <-sigChan:
+				log.Println("[!] Señal recibida. Deteniendo escaneo y guardando resultados pendientes...")
+				signal.Stop(sigChan)
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		if vhostList != "" {
+			log.Printf("[*] Resolviendo los hostnames de %s...\n", vhostList)
+			if err := loadVhostList(ctx, finder); err != nil {
+				if ctx.Err() != nil {
+					log.Println("[*] Escaneo interrumpido antes de empezar.")
+					return
+				}
+				log.Fatalf("Error al cargar --vhost-list: %v", err)
+			}
+		}
+
+		// 2. Inicializar DB (SQLite o PostgreSQL según --output)
+		store, err := storage.Open(dbPath)
 		if err != nil {
 			log.Fatalf("Error al abrir la base de datos: %v", err)
 		}
+		defer store.Close()
+		if _, err := store.Migrate(ctx); err != nil {
+			log.Fatalf("Error al migrar la base de datos: %v", err)
+		}
 
-		ipChan := make(chan string, 10000)
 		resultChan := make(chan *protocol.ServerDetail, 1000)
 		
 		// Contador para limitar la salida a 500 servidores
 		var foundCount int32
 
 		// 3. Storage Manager (Escritura en disco optimizada)
-		go storage.StartSQLiteManager(db, resultChan, 500)
-
-		// 4. Worker Pool de Análisis
-		var wg sync.WaitGroup
-		for i := 0; i < workers; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for ip := range ipChan {
-					detail, err := protocol.AnalyzeServer(ip, port, 4*time.Second)
-					if err == nil {
-						// Incrementamos el contador de forma segura entre hilos
-						count := atomic.AddInt32(&foundCount, 1)
-
-						if verbose && count <= 500 {
-							log.Printf("[+] %-15s | %-15s | P: %d/%d | WL: %t",
-								detail.IP, detail.VersionName, detail.PlayersOnline, detail.PlayersMax, detail.IsWhitelist)
-						} else if count == 501 {
-							log.Println("[*] Límite de 500 logs alcanzado. Continuando escaneo silencioso en base de datos...")
-						}
-						
-						resultChan <- detail
-					}
-				}
-			}()
+		manager := storage.NewManager(store, 500, flushEvery)
+		storageDone := manager.Start(ctx, resultChan)
+
+		// 4. Descubrimiento + Worker Pool de Análisis
+		// El protocolo se detecta en cada endpoint: el puerto no decide el analizador
+		analyze := func(ep scanner.Endpoint) (*protocol.ServerDetail, error) {
+			detail, err := protocol.DefaultRegistry.AnalyzeHost(edition, ep.IP, ep.Port, ep.Hostname, 4*time.Second)
+			// Los virtual hosts solo existen en el Server List Ping de Java
+			if err == nil && finder != nil && detail.Edition == protocol.EditionJava && !detail.RconOpen {
+				detail.VirtualHosts = finder.Probe(ctx, detail)
+			}
+			return detail, err
 		}
+		onResult := func(detail *protocol.ServerDetail) {
+			// Incrementamos el contador de forma segura entre hilos
+			count := atomic.AddInt32(&foundCount, 1)
 
-		// 5. Ejecutar Masscan
-		log.Printf("[*] Iniciando escaneo en %s (Puerto: %d, Workers: %d, Rate: %s)\n", ipRange, port, workers, rate)
-		
-		err = scanner.Run(ipRange, rate, port, excludeFile, ipChan)
-		if err != nil {
-			log.Fatalf("Error ejecutando Masscan: %v", err)
+			if verbose && count <= 500 {
+				log.Printf("[+] %-15s | %-15s | P: %d/%d | WL: %t",
+					detail.IP, detail.VersionName, detail.PlayersOnline, detail.PlayersMax, detail.IsWhitelist)
+			} else if count == 501 {
+				log.Println("[*] Límite de 500 logs alcanzado. Continuando escaneo silencioso en base de datos...")
+			}
+		}
+
+		source := ipRange
+		if discovery == discoveryList {
+			source = targetsFile
 		}
+		log.Printf("[*] Iniciando escaneo %s con %s en %s (Puertos: %s, Workers: %d, Rate: %s)\n", edition, discovery, source, portSpec, workers, rate)
 
-		// Esperar a que los workers terminen
-		wg.Wait()
+		// Un fallo del descubrimiento no descarta lo ya encontrado: primero se
+		// guarda lo pendiente y después se sale con error
+		runErr := pipeline.Run(ctx, pipeline.Config{
+			Discoverer: discoverer,
+			Analyze:    analyze,
+			Workers:    workers,
+			QueueSize:  10000,
+			OnResult:   onResult,
+		}, resultChan)
+
+		// Esperar a que el storage manager escriba el último batch
 		close(resultChan)
-		
-		// Pequeña pausa para asegurar que el storage manager termine de escribir el último batch
-		time.Sleep(1 * time.Second)
+		<-storageDone
+
+		stats := manager.Stats()
+		log.Printf("[*] Escritura: %d lotes (%d fallidos), %d filas guardadas, %d inserciones fallidas, latencia media %s (máx %s)\n",
+			stats.Batches, stats.FailedBatches, stats.Written, stats.FailedInserts,
+			stats.AvgLatency().Round(time.Millisecond), stats.MaxLatency.Round(time.Millisecond))
+
+		if runErr != nil {
+			log.Printf("[!] Error en el descubrimiento (%s): %v. Encontrados: %d. Datos en: %s\n",
+				discovery, runErr, atomic.LoadInt32(&foundCount), dbPath)
+			store.Close()
+			logFile.Close()
+			os.Exit(1)
+		}
+		if ctx.Err() != nil {
+			log.Printf("\n[*] Escaneo interrumpido. Total encontrados: %d. Datos en: %s\n", atomic.LoadInt32(&foundCount), dbPath)
+			return
+		}
 		log.Printf("\n[*] Escaneo finalizado. Total encontrados: %d. Datos en: %s\n", atomic.LoadInt32(&foundCount), dbPath)
 	},
 }
 
 func init() {
 	ScanCmd.Flags().StringVarP(&ipRange, "range", "r", "", "Rango CIDR (ej: 1.1.1.0/24)")
-	ScanCmd.Flags().StringVarP(&rate, "rate", "p", "1000", "PPS de Masscan")
-	ScanCmd.Flags().IntVar(&port, "port", 25565, "Puerto objetivo")
+	ScanCmd.Flags().StringVarP(&rate, "rate", "p", "1000", "PPS de Masscan o conexiones por segundo con --discovery connect")
+	ScanCmd.Flags().StringVar(&portSpec, "port", "25565", "Puertos objetivo: lista y rangos (ej: 25565-25575,19132)")
 	ScanCmd.Flags().IntVarP(&workers, "workers", "w", 1000, "Goroutines concurrentes")
 	ScanCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Muestra detalles de cada servidor encontrado")
 	ScanCmd.Flags().StringVar(&excludeFile, "exclude", "", "Archivo de exclusiones (rangos de IP a evitar)")
+	ScanCmd.Flags().DurationVar(&flushEvery, "flush-interval", 10*time.Second, "Intervalo máximo entre escrituras a la base de datos (0 = solo por tamaño de lote)")
+	ScanCmd.Flags().StringVar(&edition, "edition", protocol.EditionJava, "Edición a escanear: java o bedrock (UDP 19132 por defecto)")
+	ScanCmd.Flags().StringVar(&discovery, "discovery", discoveryMasscan, "Descubrimiento: masscan, connect (TCP sin root) o list (fichero de objetivos)")
+	ScanCmd.Flags().StringVar(&targetsFile, "targets", "-", "Fichero de objetivos (IPs, ip:puerto, hosts, CIDR o salida de masscan/nmap/zmap; - para stdin)")
+	ScanCmd.Flags().StringVar(&targetsFmt, "targets-format", scanner.FormatAuto, "Formato de --targets: auto, list, masscan-json, masscan-list, masscan-xml, nmap-xml o zmap-csv")
+	ScanCmd.Flags().IntVar(&connConc, "connect-concurrency", 500, "Conexiones simultáneas con --discovery connect")
+	ScanCmd.Flags().StringVar(&kickRules, "kick-rules", "", "Fichero de reglas para clasificar los kicks del login (se aplica antes que las incluidas)")
+	ScanCmd.Flags().StringSliceVar(&vhostSrcs, "vhosts", nil, "Repite el ping con hostnames de estas fuentes: ptr (DNS inverso), cert (certificado TLS del 443) y srv (_minecraft._tcp)")
+	ScanCmd.Flags().StringVar(&vhostList, "vhost-list", "", "Fichero de hostnames que se prueban contra las IPs a las que resuelven")
+	ScanCmd.Flags().StringVar(&dnsServer, "dns", "", "Servidor DNS para resolver hostnames y registros SRV (ej: 1.1.1.1); vacío usa el del sistema")
 	rootCmd.AddCommand(ScanCmd)
 }
 
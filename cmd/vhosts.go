@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"MinecraftCrawler/internal/storage"
+	"fmt"
+	"net/netip"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var vhostsLimit int
+
+var VhostsCmd = &cobra.Command{
+	Use:          "vhosts [ip|hostname]",
+	Short:        "Lista los virtual hosts encontrados con scan --vhosts o --vhost-list",
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter := storage.VirtualHostFilter{Limit: vhostsLimit}
+		if len(args) == 1 {
+			if _, err := netip.ParseAddr(args[0]); err == nil {
+				filter.IP = args[0]
+			} else {
+				filter.Hostname = args[0]
+			}
+		}
+
+		store, err := openCurrentStore(cmd.Context(), dbPath)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVIDOR\tHOSTNAME\tORIGEN\tVERSIÓN\tJUGADORES\tMOTD\tÚLTIMA VEZ")
+		total := 0
+		err = store.QueryVirtualHosts(cmd.Context(), filter, func(v *storage.VirtualHostRecord) error {
+			total++
+			fmt.Fprintf(w, "%s:%d\t%s\t%s\t%s\t%d/%d\t%s\t%s\n", v.IP, v.Port, v.Hostname, v.Source, v.VersionName,
+				v.PlayersOnline, v.PlayersMax, truncate(v.MOTD, 40), v.LastSeen.UTC().Format(time.RFC3339))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if total == 0 {
+			fmt.Fprintln(os.Stderr, "[*] No hay virtual hosts guardados")
+			return nil
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "[*] %d virtual hosts\n", total)
+		return nil
+	},
+}
+
+// truncate acorta el MOTD a una línea de como mucho n caracteres
+func truncate(s string, n int) string {
+	r := []rune(s)
+	for i, c := range r {
+		if c == '\n' {
+			r = r[:i]
+			break
+		}
+	}
+	if len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return string(r)
+}
+
+func init() {
+	VhostsCmd.Flags().IntVar(&vhostsLimit, "limit", 0, "Máximo de virtual hosts a mostrar (0 = sin límite)")
+	rootCmd.AddCommand(VhostsCmd)
+}
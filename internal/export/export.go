@@ -0,0 +1,166 @@
+package export
+
+import (
+	"MinecraftCrawler/internal/storage"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+// Writer escribe registros de servidores en un formato. Hay que llamar a
+// Close para cerrar el documento (corchete final, flush del CSV).
+type Writer interface {
+	Write(rec *storage.ServerRecord) error
+	Close() error
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatJSON:
+		return &jsonWriter{w: w}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// jsonWriter escribe el array JSON elemento a elemento para no tener la
+// tabla entera en memoria.
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonWriter) Write(rec *storage.ServerRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "[\n"
+	}
+	j.count++
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	if j.count == 0 {
+		_, err := io.WriteString(j.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(rec *storage.ServerRecord) error {
+	return n.enc.Encode(rec)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// CSVHeader son las columnas exportadas, en orden.
+var CSVHeader = []string{
+	"ip", "port", "hostname", "edition", "version_name", "protocol", "motd", "players_online", "players_max",
+	"whitelist", "software", "software_confidence", "mod_loader", "mods", "plugins", "query_players", "secure_chat", "rcon_open", "proxy_type", "protocol_min", "protocol_max", "login_outcome", "kick_category", "kick_reason", "icon_hash", "first_seen", "last_seen",
+}
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (c *csvWriter) Write(rec *storage.ServerRecord) error {
+	if !c.wroteHeader {
+		if err := c.w.Write(CSVHeader); err != nil {
+			return err
+		}
+		c.wroteHeader = true
+	}
+	return c.w.Write([]string{
+		rec.IP,
+		strconv.Itoa(rec.Port),
+		rec.Hostname,
+		rec.Edition,
+		rec.VersionName,
+		strconv.Itoa(rec.Protocol),
+		rec.MOTD,
+		strconv.Itoa(rec.PlayersOnline),
+		strconv.Itoa(rec.PlayersMax),
+		strconv.FormatBool(rec.IsWhitelist),
+		rec.Software,
+		strconv.FormatFloat(rec.SoftwareConfidence, 'f', 2, 64),
+		rec.ModLoader,
+		joinMods(rec.Mods),
+		strings.Join(rec.Plugins, ";"),
+		strings.Join(rec.QueryPlayers, ";"),
+		strconv.FormatBool(rec.EnforcesSecureChat),
+		strconv.FormatBool(rec.RconOpen),
+		rec.ProxyType,
+		strconv.Itoa(rec.ProtocolMin),
+		strconv.Itoa(rec.ProtocolMax),
+		string(rec.LoginOutcome),
+		string(rec.KickCategory),
+		rec.KickReason,
+		rec.IconHash,
+		formatTime(rec.FirstSeen),
+		formatTime(rec.LastSeen),
+	})
+}
+
+func (c *csvWriter) Close() error {
+	if !c.wroteHeader {
+		if err := c.w.Write(CSVHeader); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// joinMods escribe los mods como "modid=versión" ordenados por modid para que
+// la salida no cambie entre exportaciones.
+func joinMods(mods map[string]string) string {
+	ids := make([]string, 0, len(mods))
+	for id := range mods {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	pairs := make([]string, len(ids))
+	for i, id := range ids {
+		pairs[i] = id + "=" + mods[id]
+	}
+	return strings.Join(pairs, ";")
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
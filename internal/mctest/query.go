@@ -0,0 +1,71 @@
+package mctest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strconv"
+)
+
+const queryToken int32 = 9513307
+
+// serveQuery responde handshakes GameSpy4 y peticiones full stat hasta que
+// se cierra el socket.
+func (s *Server) serveQuery() {
+	defer s.wg.Done()
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if n < 7 || buf[0] != 0xFE || buf[1] != 0xFD {
+			continue
+		}
+		session := append([]byte(nil), buf[3:7]...)
+
+		switch buf[2] {
+		case 0x09:
+			resp := []byte{0x09}
+			resp = append(resp, session...)
+			resp = append(resp, strconv.Itoa(int(queryToken))...)
+			resp = append(resp, 0x00)
+			_, _ = s.udp.WriteTo(resp, addr)
+		case 0x00:
+			if n < 11 || int32(binary.BigEndian.Uint32(buf[7:11])) != queryToken {
+				continue
+			}
+			_, _ = s.udp.WriteTo(s.fullStat(session), addr)
+		}
+	}
+}
+
+// fullStat construye la respuesta full stat: el relleno "splitnum", las
+// claves y valores y la lista de jugadores.
+func (s *Server) fullStat(session []byte) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(0x00)
+	buf.Write(session)
+	buf.WriteString("splitnum\x00\x80\x00")
+
+	keys := make([]string, 0, len(s.cfg.Query.KV))
+	for k := range s.cfg.Query.KV {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		buf.WriteString(k)
+		buf.WriteByte(0x00)
+		buf.WriteString(s.cfg.Query.KV[k])
+		buf.WriteByte(0x00)
+	}
+	buf.WriteByte(0x00)
+
+	buf.WriteString("\x01player_\x00\x00")
+	for _, p := range s.cfg.Query.Players {
+		buf.WriteString(p)
+		buf.WriteByte(0x00)
+	}
+	buf.WriteByte(0x00)
+	return buf.Bytes()
+}
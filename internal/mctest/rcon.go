@@ -0,0 +1,33 @@
+package mctest
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"net"
+)
+
+// handleRcon es el lado servidor de Source RCON: una contraseña incorrecta
+// recibe el ID -1 y, tras un login correcto, cada comando una respuesta vacía.
+func (s *Server) handleRcon(conn net.Conn) {
+	authed := false
+	for {
+		req, err := protocol.ReadRconPacket(conn)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case req.Type == protocol.RconTypeAuth:
+			if req.Body != s.cfg.Rcon.Password || req.Body == "" {
+				_, _ = conn.Write(protocol.RconPacket{ID: -1, Type: protocol.RconTypeAuthResponse}.Encode())
+				continue
+			}
+			authed = true
+			_, _ = conn.Write(protocol.RconPacket{ID: req.ID, Type: protocol.RconTypeResponseValue}.Encode())
+			_, _ = conn.Write(protocol.RconPacket{ID: req.ID, Type: protocol.RconTypeAuthResponse}.Encode())
+		case req.Type == protocol.RconTypeExecCommand && authed:
+			_, _ = conn.Write(protocol.RconPacket{ID: req.ID, Type: protocol.RconTypeResponseValue}.Encode())
+		default:
+			return
+		}
+	}
+}
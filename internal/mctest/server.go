@@ -0,0 +1,550 @@
+// Package mctest levanta un servidor Java de Minecraft falso en loopback para
+// probar los analizadores y el pipeline de punta a punta sin red. Habla el
+// Server List Ping, el inicio del login, el ping legacy 0xFE, la query
+// GameSpy4 por UDP y Source RCON.
+package mctest
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+// Config describe el servidor a emular. El valor cero es un servidor moderno
+// con el status vacío que deja entrar a cualquiera.
+type Config struct {
+	// Status se serializa tal cual en la respuesta: puede ser un
+	// protocol.StatusResponse o un map con campos que el cliente ignora.
+	Status interface{}
+	// HostStatus sustituye a Status en los handshakes con uno de estos
+	// hostnames, como los forced hosts de un proxy.
+	HostStatus map[string]interface{}
+	// ProxyProtocols, si está definido, responde el status con el protocolo
+	// del cliente cuando cae en [min, max], como BungeeCord y Velocity.
+	ProxyProtocols [2]int
+
+	// LoginDisconnect, si no está vacío, es el chat JSON con el que se expulsa
+	// al jugador justo después del Login Start (whitelist, ban...).
+	LoginDisconnect string
+	// OnlineMode responde al Login Start con un Encryption Request.
+	OnlineMode bool
+	// LoginChannel, si no está vacío, envía un Login Plugin Request por ese
+	// canal (p. ej. "velocity:player_info") y espera la respuesta antes de seguir.
+	LoginChannel string
+	// CompressionThreshold > 0 envía Set Compression justo tras el Login Start,
+	// así que el kick o el Login Success que siguen van comprimidos.
+	CompressionThreshold int
+
+	// Legacy lo convierte en un servidor anterior a 1.7: solo entiende el ping
+	// 0xFE y expulsa los handshakes modernos.
+	Legacy *protocol.LegacyStatus
+
+	// Query activa la respuesta GameSpy4 en el mismo número de puerto por UDP.
+	Query *Query
+
+	// Rcon abre un listener RCON en su propio puerto.
+	Rcon *Rcon
+}
+
+// Query son los datos que devuelve una petición full stat.
+type Query struct {
+	KV      map[string]string
+	Players []string
+}
+
+type Rcon struct {
+	Password string
+}
+
+// Handshake es un paquete de handshake recibido por el servidor.
+type Handshake struct {
+	Protocol  int
+	Host      string
+	Port      int
+	NextState int
+}
+
+type Server struct {
+	Host     string
+	Port     int
+	RconPort int
+
+	cfg       Config
+	listener  net.Listener
+	udp       net.PacketConn
+	rcon      net.Listener
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	handshake []Handshake
+	logins    []string
+	pingHosts []string
+}
+
+// Start escucha en un puerto aleatorio de loopback. Con Query el socket UDP
+// comparte el número de puerto TCP, como en un servidor real.
+func Start(cfg Config) (*Server, error) {
+	s := &Server{cfg: cfg, Host: "127.0.0.1"}
+
+	var err error
+	for attempt := 0; attempt < 10; attempt++ {
+		if err = s.listen(); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.serve(s.listener, s.handleConn)
+	if s.udp != nil {
+		s.wg.Add(1)
+		go s.serveQuery()
+	}
+	if s.rcon != nil {
+		s.serve(s.rcon, s.handleRcon)
+	}
+	return s, nil
+}
+
+func (s *Server) listen() error {
+	l, err := net.Listen("tcp", net.JoinHostPort(s.Host, "0"))
+	if err != nil {
+		return err
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+
+	if s.cfg.Query != nil {
+		udp, err := net.ListenPacket("udp", net.JoinHostPort(s.Host, strconv.Itoa(port)))
+		if err != nil {
+			l.Close()
+			return err
+		}
+		s.udp = udp
+	}
+	if s.cfg.Rcon != nil {
+		rcon, err := net.Listen("tcp", net.JoinHostPort(s.Host, "0"))
+		if err != nil {
+			l.Close()
+			if s.udp != nil {
+				s.udp.Close()
+			}
+			return err
+		}
+		s.rcon = rcon
+		s.RconPort = rcon.Addr().(*net.TCPAddr).Port
+	}
+
+	s.listener = l
+	s.Port = port
+	return nil
+}
+
+func (s *Server) serve(l net.Listener, handle func(net.Conn)) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+				handle(conn)
+			}()
+		}
+	}()
+}
+
+// Close detiene los listeners y espera a que terminen las conexiones abiertas.
+func (s *Server) Close() {
+	s.listener.Close()
+	if s.udp != nil {
+		s.udp.Close()
+	}
+	if s.rcon != nil {
+		s.rcon.Close()
+	}
+	s.wg.Wait()
+}
+
+// Addr es la dirección del juego como host:puerto.
+func (s *Server) Addr() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// Handshakes devuelve los handshakes recibidos hasta ahora, en orden.
+func (s *Server) Handshakes() []Handshake {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Handshake(nil), s.handshake...)
+}
+
+// LegacyPingHosts devuelve los hostnames recibidos en los MC|PingHost del ping 1.6.
+func (s *Server) LegacyPingHosts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.pingHosts...)
+}
+
+// Logins devuelve los nombres enviados en los Login Start.
+func (s *Server) Logins() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.logins...)
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	first, err := r.Peek(1)
+	if err != nil {
+		return
+	}
+	if first[0] == 0xFE {
+		s.handleLegacyPing(conn, r)
+		return
+	}
+	if s.cfg.Legacy != nil {
+		// Un servidor 1.6 no entiende el handshake de netty
+		_, _ = conn.Write(legacyKick("Outdated client!"))
+		return
+	}
+
+	packet, err := readPacket(r)
+	if err != nil {
+		return
+	}
+	hs, err := parseHandshake(packet)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.handshake = append(s.handshake, hs)
+	s.mu.Unlock()
+
+	switch hs.NextState {
+	case 1:
+		s.handleStatus(conn, r, hs)
+	case 2, 3:
+		s.handleLogin(conn, r)
+	}
+}
+
+func (s *Server) handleStatus(conn net.Conn, r *bufio.Reader, hs Handshake) {
+	for {
+		packet, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		id, _ := protocol.ReadVarInt(packet)
+		switch id {
+		case 0x00:
+			status := s.statusFor(hs)
+			body := new(bytes.Buffer)
+			writeString(body, string(status))
+			if err := writePacket(conn, 0x00, body.Bytes()); err != nil {
+				return
+			}
+		case 0x01:
+			// Ping: el payload vuelve tal cual como pong
+			rest, _ := io.ReadAll(packet)
+			_ = writePacket(conn, 0x01, rest)
+			return
+		default:
+			return
+		}
+	}
+}
+
+// statusFor genera el JSON de status que ve el cliente de hs.
+func (s *Server) statusFor(hs Handshake) []byte {
+	cfg := s.cfg.Status
+	if alt, ok := s.cfg.HostStatus[hs.Host]; ok {
+		cfg = alt
+	}
+	status, err := json.Marshal(cfg)
+	if err != nil || cfg == nil {
+		status = []byte("{}")
+	}
+	if min, max := s.cfg.ProxyProtocols[0], s.cfg.ProxyProtocols[1]; max > 0 && hs.Protocol >= min && hs.Protocol <= max {
+		var m map[string]interface{}
+		if json.Unmarshal(status, &m) == nil {
+			version, _ := m["version"].(map[string]interface{})
+			if version == nil {
+				version = map[string]interface{}{}
+			}
+			version["protocol"] = hs.Protocol
+			m["version"] = version
+			status, _ = json.Marshal(m)
+		}
+	}
+	return status
+}
+
+func (s *Server) handleLogin(conn net.Conn, r *bufio.Reader) {
+	packet, err := readPacket(r)
+	if err != nil {
+		return
+	}
+	if id, _ := protocol.ReadVarInt(packet); id != 0x00 {
+		return
+	}
+	name, err := readString(packet)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.logins = append(s.logins, name)
+	s.mu.Unlock()
+
+	if s.cfg.LoginChannel != "" {
+		body := new(bytes.Buffer)
+		_ = protocol.WriteVarInt(body, 1) // ID del mensaje
+		writeString(body, s.cfg.LoginChannel)
+		if err := writePacket(conn, 0x04, body.Bytes()); err != nil {
+			return
+		}
+		// Login Plugin Response; el contenido se ignora
+		if _, err := readPacket(r); err != nil {
+			return
+		}
+	}
+
+	send := func(id int, body []byte) error { return writePacket(conn, id, body) }
+	if s.cfg.CompressionThreshold > 0 && !s.cfg.OnlineMode {
+		threshold := new(bytes.Buffer)
+		_ = protocol.WriteVarInt(threshold, s.cfg.CompressionThreshold)
+		if err := writePacket(conn, 0x03, threshold.Bytes()); err != nil {
+			return
+		}
+		send = func(id int, body []byte) error {
+			return writeCompressedPacket(conn, s.cfg.CompressionThreshold, id, body)
+		}
+	}
+
+	switch {
+	case s.cfg.LoginDisconnect != "":
+		body := new(bytes.Buffer)
+		writeString(body, s.cfg.LoginDisconnect)
+		_ = send(0x00, body.Bytes())
+	case s.cfg.OnlineMode:
+		_ = send(0x01, encryptionRequest())
+	default:
+		body := new(bytes.Buffer)
+		uuid := make([]byte, 16)
+		_, _ = rand.Read(uuid)
+		body.Write(uuid)
+		writeString(body, name)
+		_ = protocol.WriteVarInt(body, 0) // sin propiedades
+		_ = send(0x02, body.Bytes())
+	}
+}
+
+// encryptionRequest construye el Encryption Request de 1.8+. La clave es
+// aleatoria: un cliente que solo sondea nunca la usa.
+func encryptionRequest() []byte {
+	body := new(bytes.Buffer)
+	writeString(body, "")
+	key := make([]byte, 162)
+	_, _ = rand.Read(key)
+	_ = protocol.WriteVarInt(body, len(key))
+	body.Write(key)
+	token := make([]byte, 4)
+	_, _ = rand.Read(token)
+	_ = protocol.WriteVarInt(body, len(token))
+	body.Write(token)
+	body.WriteByte(0x01) // debe autenticar (1.20.5+)
+	return body.Bytes()
+}
+
+func (s *Server) handleLegacyPing(conn net.Conn, r *bufio.Reader) {
+	// Se descarta lo que envíe el cliente; el ping 1.6 añade el plugin message MC|PingHost
+	_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	request, _ := io.ReadAll(io.LimitReader(r, 1024))
+	if host, ok := parsePingHost(request); ok {
+		s.mu.Lock()
+		s.pingHosts = append(s.pingHosts, host)
+		s.mu.Unlock()
+	}
+
+	status := s.legacyStatus()
+	if len(request) < 2 || request[1] != 0x01 {
+		// Ping de Beta 1.8 a 1.3: solo MOTD y jugadores
+		_, _ = conn.Write(legacyKick(fmt.Sprintf("%s§%d§%d", status.MOTD, status.PlayersOnline, status.PlayersMax)))
+		return
+	}
+	_, _ = conn.Write(legacyKick(fmt.Sprintf("§1\x00%d\x00%s\x00%s\x00%d\x00%d",
+		status.Protocol, status.VersionName, status.MOTD, status.PlayersOnline, status.PlayersMax)))
+}
+
+// legacyStatus es lo que el servidor responde a un ping 0xFE: el status legacy
+// configurado o, en un servidor moderno, un resumen del status.
+func (s *Server) legacyStatus() protocol.LegacyStatus {
+	if s.cfg.Legacy != nil {
+		return *s.cfg.Legacy
+	}
+	status := protocol.LegacyStatus{Protocol: 127, VersionName: "1.20.4"}
+	if raw, err := json.Marshal(s.cfg.Status); err == nil {
+		var parsed protocol.StatusResponse
+		if json.Unmarshal(raw, &parsed) == nil {
+			if parsed.Version.Name != "" {
+				status.VersionName = parsed.Version.Name
+			}
+			status.MOTD = protocol.ParseChat(parsed.Description).PlainText()
+			status.PlayersOnline = parsed.Players.Online
+			status.PlayersMax = parsed.Players.Max
+		}
+	}
+	return status
+}
+
+// parsePingHost extrae el hostname de un ping 1.6: 0xFE 0x01 0xFA, el canal
+// MC|PingHost, la longitud de los datos, el protocolo, el host y el puerto.
+func parsePingHost(request []byte) (string, bool) {
+	r := bytes.NewReader(request)
+	var head [3]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil || head != [3]byte{0xFE, 0x01, 0xFA} {
+		return "", false
+	}
+	if _, err := readUTF16(r); err != nil {
+		return "", false
+	}
+	var dataLen uint16
+	var proto byte
+	if binary.Read(r, binary.BigEndian, &dataLen) != nil || binary.Read(r, binary.BigEndian, &proto) != nil {
+		return "", false
+	}
+	host, err := readUTF16(r)
+	return host, err == nil
+}
+
+// readUTF16 lee una cadena del protocolo legacy: longitud en unidades UTF-16 y el texto.
+func readUTF16(r io.Reader) (string, error) {
+	var n uint16
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return "", err
+	}
+	units := make([]uint16, n)
+	if err := binary.Read(r, binary.BigEndian, units); err != nil {
+		return "", err
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+func legacyKick(msg string) []byte {
+	units := utf16.Encode([]rune(msg))
+	buf := new(bytes.Buffer)
+	buf.WriteByte(0xFF)
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(units)))
+	_ = binary.Write(buf, binary.BigEndian, units)
+	return buf.Bytes()
+}
+
+func parseHandshake(packet *bytes.Reader) (Handshake, error) {
+	var hs Handshake
+	if id, err := protocol.ReadVarInt(packet); err != nil || id != 0x00 {
+		return hs, errors.New("not a handshake")
+	}
+	var err error
+	if hs.Protocol, err = protocol.ReadVarInt(packet); err != nil {
+		return hs, err
+	}
+	if hs.Host, err = readString(packet); err != nil {
+		return hs, err
+	}
+	var port uint16
+	if err := binary.Read(packet, binary.BigEndian, &port); err != nil {
+		return hs, err
+	}
+	hs.Port = int(port)
+	hs.NextState, err = protocol.ReadVarInt(packet)
+	return hs, err
+}
+
+// readPacket lee una trama precedida de su longitud.
+func readPacket(r io.Reader) (*bytes.Reader, error) {
+	length, err := protocol.ReadVarIntSafe(r)
+	if err != nil {
+		return nil, err
+	}
+	if length <= 0 || length > 1<<21 {
+		return nil, fmt.Errorf("invalid packet length %d", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+func writePacket(w io.Writer, id int, body []byte) error {
+	payload := new(bytes.Buffer)
+	_ = protocol.WriteVarInt(payload, id)
+	payload.Write(body)
+
+	frame := new(bytes.Buffer)
+	_ = protocol.WriteVarInt(frame, payload.Len())
+	frame.Write(payload.Bytes())
+	_, err := w.Write(frame.Bytes())
+	return err
+}
+
+// writeCompressedPacket usa las tramas posteriores a Set Compression: el
+// contenido lleva su longitud sin comprimir, o 0 si va tal cual.
+func writeCompressedPacket(w io.Writer, threshold int, id int, body []byte) error {
+	payload := new(bytes.Buffer)
+	_ = protocol.WriteVarInt(payload, id)
+	payload.Write(body)
+
+	inner := new(bytes.Buffer)
+	if payload.Len() < threshold {
+		_ = protocol.WriteVarInt(inner, 0)
+		inner.Write(payload.Bytes())
+	} else {
+		_ = protocol.WriteVarInt(inner, payload.Len())
+		zw := zlib.NewWriter(inner)
+		_, _ = zw.Write(payload.Bytes())
+		_ = zw.Close()
+	}
+
+	frame := new(bytes.Buffer)
+	_ = protocol.WriteVarInt(frame, inner.Len())
+	frame.Write(inner.Bytes())
+	_, err := w.Write(frame.Bytes())
+	return err
+}
+
+func readString(r io.Reader) (string, error) {
+	n, err := protocol.ReadVarIntSafe(r)
+	if err != nil {
+		return "", err
+	}
+	if n < 0 || n > 1<<16 {
+		return "", fmt.Errorf("invalid string length %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func writeString(w *bytes.Buffer, s string) {
+	_ = protocol.WriteVarInt(w, len(s))
+	w.WriteString(s)
+}
@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"MinecraftCrawler/internal/scanner"
+	"context"
+	"sync"
+)
+
+// AnalyzeFunc extrae los detalles de un endpoint descubierto.
+type AnalyzeFunc func(ep scanner.Endpoint) (*protocol.ServerDetail, error)
+
+// Config conecta un Discoverer con un pool de analizadores.
+type Config struct {
+	Discoverer scanner.Discoverer
+	Analyze    AnalyzeFunc
+	Workers    int
+	// QueueSize es el buffer entre descubrimiento y análisis.
+	QueueSize int
+	// OnResult, si no es nil, se llama por cada servidor analizado antes de
+	// enviarlo a results.
+	OnResult func(*protocol.ServerDetail)
+}
+
+// Run descubre endpoints, los analiza con cfg.Workers goroutines y envía cada
+// resultado a results, que no se cierra. Vuelve cuando el descubrimiento ha
+// terminado y todos los análisis en curso han acabado. Tras cancelar ctx se
+// descarta lo que quede en cola.
+func Run(ctx context.Context, cfg Config, results chan<- *protocol.ServerDetail) error {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	queue := make(chan scanner.Endpoint, cfg.QueueSize)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ep := range queue {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				detail, err := cfg.Analyze(ep)
+				if err != nil {
+					continue
+				}
+				if cfg.OnResult != nil {
+					cfg.OnResult(detail)
+				}
+				results <- detail
+			}
+		}()
+	}
+
+	err := cfg.Discoverer.Discover(ctx, queue)
+	wg.Wait()
+	return err
+}
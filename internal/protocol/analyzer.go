@@ -1,13 +1,18 @@
 package protocol
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -18,18 +23,40 @@ type StatusResponse struct {
 		Protocol int    `json:"protocol"`
 	} `json:"version"`
 	Players struct {
-		Max    int `json:"max"`
-		Online int `json:"online"`
+		Max    int            `json:"max"`
+		Online int            `json:"online"`
+		Sample []PlayerSample `json:"sample"`
 	} `json:"players"`
 	Description        interface{} `json:"description"`
 	Favicon            string      `json:"favicon"`
 	EnforcesSecureChat bool        `json:"enforcesSecureChat"`
-	ForgeData          struct {
-		Mods []struct {
-			ModID   string `json:"modid"`
-			Version string `json:"version"`
-		} `json:"mods"`
-	} `json:"forgeData"`
+	ForgeData          *ForgeData  `json:"forgeData"`
+	ModInfo            *ModInfo    `json:"modinfo"`
+	IsModded           bool        `json:"isModded"`
+	// PreventsChatReports lo añaden NoChatReports y plugins similares
+	PreventsChatReports bool `json:"preventsChatReports"`
+}
+
+// PlayerSample es una entrada de players.sample en el Server List Ping.
+type PlayerSample struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// nilPlayerUUID lo usan los plugins que rellenan el sample con líneas de texto
+const nilPlayerUUID = "00000000-0000-0000-0000-000000000000"
+
+// realPlayers descarta las entradas del sample que no son jugadores: UUID
+// nulo o vacío y nombres con códigos de color, típicos de los MOTD de hover.
+func realPlayers(sample []PlayerSample) []PlayerSample {
+	var players []PlayerSample
+	for _, p := range sample {
+		if p.ID == "" || p.ID == nilPlayerUUID || p.Name == "" || strings.Contains(p.Name, "§") {
+			continue
+		}
+		players = append(players, p)
+	}
+	return players
 }
 
 func sendHandshake(conn net.Conn, host string, port int, protocol int, nextState int) error {
@@ -49,17 +76,39 @@ func sendHandshake(conn net.Conn, host string, port int, protocol int, nextState
 }
 
 func AnalyzeServer(ip string, port int, timeout time.Duration) (*ServerDetail, error) {
+	return AnalyzeServerHost(ip, port, "", timeout)
+}
+
+// AnalyzeServerHost analiza ip:port anunciando hostname en los handshakes,
+// como hace el cliente al conectarse por dominio. Sin hostname se anuncia la IP.
+func AnalyzeServerHost(ip string, port int, hostname string, timeout time.Duration) (*ServerDetail, error) {
 	detail := &ServerDetail{
-		IP: ip, Port: port, Timestamp: time.Now(), Mods: make(map[string]string),
+		IP: ip, Port: port, Hostname: hostname, Edition: EditionJava, Timestamp: time.Now(), Mods: make(map[string]string),
 	}
-
-	if port == 25575 {
-		return analyzeRcon(detail, timeout)
+	hsHost := hostname
+	if hsHost == "" {
+		hsHost = ip
 	}
 
-	status, err := GetServerStatus(ip, port, timeout)
+	status, err := getStatus(ip, port, hsHost, statusProtocol, timeout)
 	if err != nil {
-		return nil, err
+		if isDialError(err) {
+			return nil, err
+		}
+		// Los servidores anteriores a 1.7 expulsan el handshake moderno con un
+		// 0xFF o cortan la conexión; un timeout no justifica tres conexiones más.
+		if !isLegacyCandidate(err) {
+			return nil, err
+		}
+		legacy, legacyErr := getLegacyStatus(ip, port, hsHost, timeout)
+		if legacyErr != nil {
+			return nil, err
+		}
+		applyLegacyStatus(detail, legacy)
+		applyQueryInfo(detail, ip, port)
+		applyProxyInfo(detail, nil)
+		detail.Software, detail.SoftwareConfidence = FingerprintSoftware(detail, nil, nil)
+		return detail, nil
 	}
 
 	detail.VersionName = status.Version.Name
@@ -67,120 +116,129 @@ func AnalyzeServer(ip string, port int, timeout time.Duration) (*ServerDetail, e
 	detail.PlayersMax = status.Players.Max
 	detail.PlayersOnline = status.Players.Online
 	detail.EnforcesSecureChat = status.EnforcesSecureChat
+	detail.PlayerSample = realPlayers(status.Players.Sample)
 
-	if status.Favicon != "" {
-		b64 := strings.TrimPrefix(status.Favicon, "data:image/png;base64,")
-		img, _ := base64.StdEncoding.DecodeString(b64)
-		detail.Icon = img
-	}
+	motd := ParseChat(status.Description)
+	detail.MOTD = motd.PlainText()
+	detail.MOTDJSON = motd.JSON()
 
-	for _, m := range status.ForgeData.Mods {
-		detail.Mods[m.ModID] = m.Version
-	}
+	detail.Icon, detail.IconHash = decodeFavicon(status.Favicon)
 
-	connLogin, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", ip, port), timeout)
-	if err == nil {
-		defer connLogin.Close()
-		_ = sendHandshake(connLogin, ip, port, detail.Protocol, 2)
-
-		ls := new(bytes.Buffer)
-		_ = WriteVarInt(ls, 0x00)
-		username := "GeminiCrawler"
-		_ = WriteVarInt(ls, len(username))
-		_, _ = ls.WriteString(username)
-		
-		uuid := []byte{0xDE, 0xAD, 0xBE, 0xEF, 0xDE, 0xAD, 0xBE, 0xEF, 0xDE, 0xAD, 0xBE, 0xEF, 0xDE, 0xAD, 0xBE, 0xEF}
-		if detail.Protocol >= 764 {
-			_, _ = ls.Write(uuid)
-		} else if detail.Protocol >= 759 {
-			_ = ls.WriteByte(0x01)
-			_, _ = ls.Write(uuid)
-		}
+	applyModData(detail, status)
+	proxy := probeProxy(ip, port, hsHost, status, timeout)
 
-		frame := new(bytes.Buffer)
-		_ = WriteVarInt(frame, ls.Len())
-		_, _ = frame.Write(ls.Bytes())
-		_, err = connLogin.Write(frame.Bytes()) // Error checked
-		if err == nil {
-			_, err = ReadVarInt(connLogin)
-			if err == nil {
-				pID, _ := ReadVarInt(connLogin)
-				if pID == 0x00 {
-					rLen, _ := ReadVarInt(connLogin)
-					reason := make([]byte, rLen)
-					_, _ = io.ReadFull(connLogin, reason) // Silenced explicitly
-					msg := strings.ToLower(string(reason))
-					if strings.Contains(msg, "whitelist") || strings.Contains(msg, "not on the list") {
-						detail.IsWhitelist = true
-					}
-				}
-			}
-		}
-	}
-
-	query, err := GetQueryInfo(ip, port, 2*time.Second)
-	if err == nil {
-		detail.Plugins = query.Plugins
-		if query.Software != "" {
-			detail.Software = query.Software
-		}
+	// Un login fallido no invalida el estado ya leído: el resultado queda vacío
+	login, _ := probeLogin(ip, port, hsHost, detail.Protocol, timeout)
+	if login != nil {
+		detail.LoginOutcome = login.Outcome
+		detail.KickReason = login.Reason.PlainText()
+		detail.KickCategory = login.Kick
+		detail.IsWhitelist = login.Outcome == LoginWhitelisted
 	}
 
+	applyQueryInfo(detail, ip, port)
+	applyProxyInfo(detail, proxy)
+	detail.Software, detail.SoftwareConfidence = FingerprintSoftware(detail, status, login)
 	return detail, nil
 }
 
-func analyzeRcon(detail *ServerDetail, timeout time.Duration) (*ServerDetail, error) {
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", detail.IP, detail.Port), timeout)
-	if err != nil {
-		return nil, err
+// decodeFavicon extrae el PNG del data URI del status y su hash.
+func decodeFavicon(favicon string) ([]byte, string) {
+	if favicon == "" {
+		return nil, ""
 	}
-	defer conn.Close()
+	img, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(favicon, "data:image/png;base64,"))
+	if err != nil || len(img) == 0 {
+		return nil, ""
+	}
+	sum := sha256.Sum256(img)
+	return img, hex.EncodeToString(sum[:])
+}
 
-	payload := ""
-	packet := new(bytes.Buffer)
-	_ = binary.Write(packet, binary.LittleEndian, int32(len(payload)+10))
-	_ = binary.Write(packet, binary.LittleEndian, int32(1))
-	_ = binary.Write(packet, binary.LittleEndian, int32(3))
-	_, _ = packet.WriteString(payload)
-	_, _ = packet.Write([]byte{0x00, 0x00})
+func applyLegacyStatus(detail *ServerDetail, legacy *LegacyStatus) {
+	detail.VersionName = legacy.VersionName
+	detail.Protocol = legacy.Protocol
+	detail.PlayersMax = legacy.PlayersMax
+	detail.PlayersOnline = legacy.PlayersOnline
 
-	_ = conn.SetDeadline(time.Now().Add(timeout))
-	_, err = conn.Write(packet.Bytes())
+	motd := ParseChat(legacy.MOTD)
+	detail.MOTD = motd.PlainText()
+	detail.MOTDJSON = motd.JSON()
+}
+
+func applyQueryInfo(detail *ServerDetail, ip string, port int) {
+	query, err := GetQueryInfo(ip, port, 2*time.Second)
 	if err != nil {
-		return nil, err
+		return
 	}
+	detail.Plugins = query.Plugins
+	detail.QueryKV = query.RawKV
+	detail.QueryPlayers = query.Players
+	if detail.LevelName == "" {
+		detail.LevelName = query.MapName
+	}
+}
 
-	detail.RconOpen = true
-	detail.Software = "RCON Service"
-	return detail, nil
+// Errores del ping moderno que apuntan a un servidor anterior a 1.7.
+var (
+	errLegacyKick   = errors.New("legacy 0xFF kick")
+	errInvalidFrame = errors.New("invalid frame")
+)
+
+// isLegacyCandidate decide si merece la pena el ping legacy tras un fallo
+// del moderno: kick 0xFF, conexión cerrada o trama que no es del protocolo.
+func isLegacyCandidate(err error) bool {
+	return errors.Is(err, errLegacyKick) || errors.Is(err, errInvalidFrame) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isDialError indica que ni siquiera se pudo conectar: el puerto está cerrado
+// y no tiene sentido probar otro protocolo.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
 }
 
 func GetServerStatus(host string, port int, timeout time.Duration) (*StatusResponse, error) {
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", host, port), timeout)
+	return getStatus(host, port, host, statusProtocol, timeout)
+}
+
+// getStatus hace el ping anunciando hsHost y protocolVersion en el handshake.
+func getStatus(host string, port int, hsHost string, protocolVersion int, timeout time.Duration) (*StatusResponse, error) {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), timeout)
 	if err != nil { return nil, err }
 	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
 
-	_ = sendHandshake(conn, host, port, 763, 1)
-	sr := new(bytes.Buffer)
-	_ = WriteVarInt(sr, 0x00)
-	f := new(bytes.Buffer)
-	_ = WriteVarInt(f, sr.Len())
-	_, _ = f.Write(sr.Bytes())
-	_, _ = conn.Write(f.Bytes())
+	_ = sendHandshake(conn, hsHost, port, protocolVersion, 1)
+	br := bufio.NewReader(conn)
+	pc := NewPacketConn(struct {
+		io.Reader
+		io.Writer
+	}{br, conn})
+	if err := pc.WritePacket([]byte{0x00}); err != nil {
+		return nil, err
+	}
 
-	_, err = ReadVarInt(conn)
-	if err != nil { return nil, err }
-	pID, _ := ReadVarInt(conn)
-	if pID != 0x00 { return nil, fmt.Errorf("id error") }
-	jLen, err := ReadVarIntSafe(conn)
-	if err != nil { return nil, err }
-	
-	jBytes := make([]byte, jLen)
-	_, err = io.ReadFull(conn, jBytes)
-	if err != nil { return nil, err }
+	// El kick legacy es 0xFF y una longitud de 16 bits; una trama moderna
+	// puede empezar por 0xFF, pero nunca seguido de 0x00 (varint no canónico)
+	if head, err := br.Peek(2); err == nil && head[0] == 0xFF && head[1] == 0x00 {
+		return nil, errLegacyKick
+	}
+	pID, body, err := pc.ReadPacket()
+	if err != nil {
+		return nil, err
+	}
+	if pID != 0x00 {
+		return nil, fmt.Errorf("%w: status packet id 0x%02x", errInvalidFrame, pID)
+	}
+	raw, err := readString(body)
+	if err != nil {
+		return nil, err
+	}
 
 	var res StatusResponse
-	if err := json.Unmarshal(jBytes, &res); err != nil { return nil, err }
+	if err := json.Unmarshal([]byte(raw), &res); err != nil { return nil, err }
 	return &res, nil
 }
 
@@ -193,7 +251,7 @@ func ReadVarIntSafe(r io.Reader) (int, error) {
 		value |= int(b[0]&0x7F) << shift
 		if (b[0] & 0x80) == 0 { break }
 		shift += 7
-		if shift > 35 { return 0, fmt.Errorf("varint too big") }
+		if shift > 35 { return 0, fmt.Errorf("%w: varint too big", errInvalidFrame) }
 	}
 	return value, nil
 }
\ No newline at end of file
@@ -0,0 +1,152 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EditionJava    = "java"
+	EditionBedrock = "bedrock"
+)
+
+// DefaultBedrockPort es el puerto por defecto de Bedrock Dedicated Server.
+const DefaultBedrockPort = 19132
+
+const (
+	raknetUnconnectedPing = 0x01
+	raknetUnconnectedPong = 0x1C
+)
+
+// raknetMagic es el identificador offline que llevan los paquetes RakNet sin conexión.
+var raknetMagic = []byte{0x00, 0xFF, 0xFF, 0x00, 0xFE, 0xFE, 0xFE, 0xFE, 0xFD, 0xFD, 0xFD, 0xFD, 0x12, 0x34, 0x56, 0x78}
+
+type BedrockStatus struct {
+	Edition       string
+	MOTD          string
+	Protocol      int
+	VersionName   string
+	PlayersOnline int
+	PlayersMax    int
+	ServerGUID    string
+	LevelName     string
+	GameMode      string
+	PortV4        int
+	PortV6        int
+}
+
+// BedrockPingPacket construye un RakNet Unconnected Ping. También es el
+// payload UDP que envía masscan para descubrir servidores Bedrock.
+func BedrockPingPacket(clientGUID int64) []byte {
+	buf := new(bytes.Buffer)
+	_ = buf.WriteByte(raknetUnconnectedPing)
+	_ = binary.Write(buf, binary.BigEndian, time.Now().UnixMilli())
+	_, _ = buf.Write(raknetMagic)
+	_ = binary.Write(buf, binary.BigEndian, clientGUID)
+	return buf.Bytes()
+}
+
+func GetBedrockStatus(ip string, port int, timeout time.Duration) (*BedrockStatus, error) {
+	conn, err := net.DialTimeout("udp", net.JoinHostPort(ip, strconv.Itoa(port)), timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := conn.Write(BedrockPingPacket(0x4D43437261776C)); err != nil {
+		return nil, err
+	}
+
+	resp := make([]byte, 2048)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBedrockPong(resp[:n])
+}
+
+// ParseBedrockPong decodifica un RakNet Unconnected Pong y su cadena de
+// identificación MCPE/MCEE.
+func ParseBedrockPong(packet []byte) (*BedrockStatus, error) {
+	// id(1) + tiempo(8) + guid del servidor(8) + magic(16) + longitud(2)
+	const headerLen = 1 + 8 + 8 + 16 + 2
+	if len(packet) < headerLen || packet[0] != raknetUnconnectedPong {
+		return nil, fmt.Errorf("not a raknet pong")
+	}
+	if !bytes.Equal(packet[17:33], raknetMagic) {
+		return nil, fmt.Errorf("invalid raknet magic")
+	}
+	strLen := int(binary.BigEndian.Uint16(packet[33:35]))
+	if len(packet) < headerLen+strLen {
+		return nil, fmt.Errorf("truncated raknet pong")
+	}
+
+	fields := strings.Split(string(packet[headerLen:headerLen+strLen]), ";")
+	if len(fields) < 6 {
+		return nil, fmt.Errorf("malformed server id string")
+	}
+
+	field := func(i int) string {
+		if i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+	atoi := func(i int) int {
+		v, _ := strconv.Atoi(field(i))
+		return v
+	}
+
+	status := &BedrockStatus{
+		Edition:       field(0),
+		MOTD:          field(1),
+		Protocol:      atoi(2),
+		VersionName:   field(3),
+		PlayersOnline: atoi(4),
+		PlayersMax:    atoi(5),
+		ServerGUID:    field(6),
+		LevelName:     field(7),
+		GameMode:      field(8),
+		PortV4:        atoi(10),
+		PortV6:        atoi(11),
+	}
+	if status.ServerGUID == "" {
+		status.ServerGUID = strconv.FormatUint(binary.BigEndian.Uint64(packet[9:17]), 10)
+	}
+	return status, nil
+}
+
+func AnalyzeBedrock(ip string, port int, timeout time.Duration) (*ServerDetail, error) {
+	status, err := GetBedrockStatus(ip, port, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	motd := ParseChat(status.MOTD)
+	detail := &ServerDetail{
+		IP:            ip,
+		Port:          port,
+		Edition:       EditionBedrock,
+		Timestamp:     time.Now(),
+		VersionName:   status.VersionName,
+		Protocol:      status.Protocol,
+		MOTD:          motd.PlainText(),
+		MOTDJSON:      motd.JSON(),
+		PlayersOnline: status.PlayersOnline,
+		PlayersMax:    status.PlayersMax,
+		Mods:          make(map[string]string),
+		ServerGUID:    status.ServerGUID,
+		LevelName:     status.LevelName,
+		GameMode:      status.GameMode,
+		PortV4:        status.PortV4,
+		PortV6:        status.PortV6,
+	}
+	detail.Software, detail.SoftwareConfidence = FingerprintSoftware(detail, nil, nil)
+	return detail, nil
+}
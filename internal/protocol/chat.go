@@ -0,0 +1,280 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChatComponent es un componente de texto de Minecraft normalizado. Los
+// códigos § se convierten en campos de color y formato explícitos.
+type ChatComponent struct {
+	Text          string          `json:"text"`
+	Translate     string          `json:"translate,omitempty"`
+	With          []ChatComponent `json:"with,omitempty"`
+	Color         string          `json:"color,omitempty"`
+	Bold          *bool           `json:"bold,omitempty"`
+	Italic        *bool           `json:"italic,omitempty"`
+	Underlined    *bool           `json:"underlined,omitempty"`
+	Strikethrough *bool           `json:"strikethrough,omitempty"`
+	Obfuscated    *bool           `json:"obfuscated,omitempty"`
+	Extra         []ChatComponent `json:"extra,omitempty"`
+}
+
+// translations cubre las claves vanilla habituales en MOTDs y kicks. Una clave
+// desconocida se muestra tal cual, como hace el cliente.
+var translations = map[string]string{
+	"chat.type.text":                                   "<%s> %s",
+	"chat.type.announcement":                           "[%s] %s",
+	"multiplayer.disconnect.not_whitelisted":           "You are not white-listed on this server!",
+	"multiplayer.disconnect.banned":                    "You are banned from this server.",
+	"multiplayer.disconnect.banned.reason":             "You are banned from this server.\nReason: %s",
+	"multiplayer.disconnect.banned.expiration":         "\nYour ban will be removed on %s",
+	"multiplayer.disconnect.banned_ip.reason":          "Your IP address is banned from this server.\nReason: %s",
+	"multiplayer.disconnect.server_full":               "Server is full!",
+	"multiplayer.disconnect.outdated_client":           "Incompatible client! Please use %s",
+	"multiplayer.disconnect.incompatible":              "Incompatible client! Please use %s",
+	"multiplayer.disconnect.server_shutdown":           "Server closed",
+	"multiplayer.disconnect.unverified_username":       "Failed to verify username!",
+	"multiplayer.disconnect.authservers_down":          "Authentication servers are down. Please try again later, sorry!",
+	"multiplayer.disconnect.generic":                   "Disconnected",
+	"disconnect.genericReason":                         "%s",
+	"multiplayer.disconnect.name_taken":                "That name is already taken",
+	"multiplayer.disconnect.duplicate_login":           "You logged in from another location",
+	"multiplayer.disconnect.invalid_player_data":       "Invalid player data",
+	"multiplayer.disconnect.unexpected_query_response": "Unexpected custom data from client",
+}
+
+var legacyColors = map[byte]string{
+	'0': "black", '1': "dark_blue", '2': "dark_green", '3': "dark_aqua",
+	'4': "dark_red", '5': "dark_purple", '6': "gold", '7': "gray",
+	'8': "dark_gray", '9': "blue", 'a': "green", 'b': "aqua",
+	'c': "red", 'd': "light_purple", 'e': "yellow", 'f': "white",
+}
+
+// UnmarshalJSON acepta todas las formas que permite el protocolo: cadenas,
+// números, booleanos, arrays (el primero es el padre) y objetos.
+func (c *ChatComponent) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = ParseChat(raw)
+	return nil
+}
+
+// ParseChat convierte un chat ya decodificado por encoding/json en un
+// interface{} en un árbol de ChatComponent.
+func ParseChat(raw interface{}) ChatComponent {
+	switch v := raw.(type) {
+	case nil:
+		return ChatComponent{}
+	case string:
+		return parseLegacy(v)
+	case float64:
+		return ChatComponent{Text: strconv.FormatFloat(v, 'f', -1, 64)}
+	case bool:
+		return ChatComponent{Text: strconv.FormatBool(v)}
+	case []interface{}:
+		if len(v) == 0 {
+			return ChatComponent{}
+		}
+		root := ParseChat(v[0])
+		for _, child := range v[1:] {
+			root.Extra = append(root.Extra, ParseChat(child))
+		}
+		return root
+	case map[string]interface{}:
+		return parseObject(v)
+	default:
+		return ChatComponent{Text: fmt.Sprint(v)}
+	}
+}
+
+func parseObject(obj map[string]interface{}) ChatComponent {
+	var c ChatComponent
+
+	switch t := obj["text"].(type) {
+	case string:
+		c = parseLegacy(t)
+	case float64, bool:
+		c.Text = fmt.Sprint(t)
+	}
+	if kb, ok := obj["keybind"].(string); ok && c.Text == "" {
+		c.Text = kb
+	}
+	if tr, ok := obj["translate"].(string); ok {
+		c.Translate = tr
+		if with, ok := obj["with"].([]interface{}); ok {
+			for _, arg := range with {
+				c.With = append(c.With, ParseChat(arg))
+			}
+		}
+	}
+
+	if color, ok := obj["color"].(string); ok {
+		c.Color = color
+	}
+	c.Bold = boolField(obj, "bold", c.Bold)
+	c.Italic = boolField(obj, "italic", c.Italic)
+	c.Underlined = boolField(obj, "underlined", c.Underlined)
+	c.Strikethrough = boolField(obj, "strikethrough", c.Strikethrough)
+	c.Obfuscated = boolField(obj, "obfuscated", c.Obfuscated)
+
+	if extra, ok := obj["extra"].([]interface{}); ok {
+		for _, child := range extra {
+			c.Extra = append(c.Extra, ParseChat(child))
+		}
+	}
+	return c
+}
+
+func boolField(obj map[string]interface{}, key string, fallback *bool) *bool {
+	if b, ok := obj[key].(bool); ok {
+		return &b
+	}
+	return fallback
+}
+
+// parseLegacy parte un texto con códigos § en un componente cuyos hijos
+// llevan los estilos decodificados.
+func parseLegacy(s string) ChatComponent {
+	if !strings.ContainsRune(s, '§') {
+		return ChatComponent{Text: s}
+	}
+
+	var root ChatComponent
+	var cur ChatComponent
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		cur.Text = text.String()
+		root.Extra = append(root.Extra, cur)
+		text.Reset()
+	}
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '§' || i+1 >= len(runes) {
+			text.WriteRune(runes[i])
+			continue
+		}
+		code := byte(strings.ToLower(string(runes[i+1]))[0])
+		i++
+
+		// §x§R§R§G§G§B§B es la extensión de Spigot para colores hex
+		if code == 'x' && i+12 < len(runes) {
+			var hex strings.Builder
+			for j := 0; j < 6; j++ {
+				hex.WriteRune(runes[i+2+j*2])
+			}
+			flush()
+			cur = ChatComponent{Color: "#" + strings.ToLower(hex.String())}
+			i += 12
+			continue
+		}
+
+		flush()
+		t := true
+		switch {
+		case legacyColors[code] != "":
+			// Un color reinicia el formato, igual que en el cliente vanilla
+			cur = ChatComponent{Color: legacyColors[code]}
+		case code == 'l':
+			cur.Bold = &t
+		case code == 'o':
+			cur.Italic = &t
+		case code == 'n':
+			cur.Underlined = &t
+		case code == 'm':
+			cur.Strikethrough = &t
+		case code == 'k':
+			cur.Obfuscated = &t
+		case code == 'r':
+			cur = ChatComponent{}
+		}
+	}
+	flush()
+
+	if len(root.Extra) == 1 {
+		return root.Extra[0]
+	}
+	return root
+}
+
+// PlainText devuelve el texto del árbol sin formato.
+func (c ChatComponent) PlainText() string {
+	var b strings.Builder
+	c.writePlain(&b)
+	return b.String()
+}
+
+func (c ChatComponent) writePlain(b *strings.Builder) {
+	if c.Translate != "" {
+		b.WriteString(c.translated())
+	} else {
+		b.WriteString(c.Text)
+	}
+	for _, child := range c.Extra {
+		child.writePlain(b)
+	}
+}
+
+func (c ChatComponent) translated() string {
+	format, ok := translations[c.Translate]
+	if !ok {
+		format = c.Translate
+	}
+
+	args := make([]string, len(c.With))
+	for i, w := range c.With {
+		args[i] = w.PlainText()
+	}
+
+	var b strings.Builder
+	next := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 >= len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		// El cliente solo usa %s, %N$s y %%
+		rest := format[i+1:]
+		switch {
+		case rest[0] == '%':
+			b.WriteByte('%')
+			i++
+		case rest[0] == 's':
+			if next < len(args) {
+				b.WriteString(args[next])
+			}
+			next++
+			i++
+		default:
+			end := strings.Index(rest, "$s")
+			idx, err := strconv.Atoi(rest[:max(end, 0)])
+			if end <= 0 || err != nil {
+				b.WriteByte('%')
+				continue
+			}
+			if idx >= 1 && idx <= len(args) {
+				b.WriteString(args[idx-1])
+			}
+			i += end + 2
+		}
+	}
+	return b.String()
+}
+
+// JSON devuelve el árbol normalizado serializado en JSON.
+func (c ChatComponent) JSON() string {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,147 @@
+package protocol
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Implementaciones que reconoce FingerprintSoftware. El orden desempata
+// cuando dos candidatas suman la misma confianza: los forks más concretos
+// van antes que el proyecto del que derivan.
+var knownSoftware = []string{
+	"Folia", "Purpur", "Paper", "Spigot", "CraftBukkit",
+	"NeoForge", "Forge", "Quilt", "Fabric", "Sponge",
+	"Velocity", "Waterfall", "BungeeCord", "Geyser", "Limbo",
+	"Bedrock Dedicated Server", "Vanilla",
+}
+
+type softwareRule struct {
+	software string
+	pattern  *regexp.Regexp
+}
+
+// nameRules se aplican a los textos que nombran la implementación: la
+// versión del status y el software que declara Query.
+var nameRules = []softwareRule{
+	{"Folia", regexp.MustCompile(`(?i)\bfolia\b`)},
+	{"Purpur", regexp.MustCompile(`(?i)\bpurpur\b`)},
+	{"Paper", regexp.MustCompile(`(?i)\bpaper(mc|spigot)?\b`)},
+	{"Spigot", regexp.MustCompile(`(?i)\bspigot\b`)},
+	{"CraftBukkit", regexp.MustCompile(`(?i)\b(craft)?bukkit\b`)},
+	{"NeoForge", regexp.MustCompile(`(?i)\bneoforge\b`)},
+	{"Forge", regexp.MustCompile(`(?i)\b(forge|fml)\b`)},
+	{"Quilt", regexp.MustCompile(`(?i)\bquilt\b`)},
+	{"Fabric", regexp.MustCompile(`(?i)\bfabric\b`)},
+	{"Sponge", regexp.MustCompile(`(?i)\bsponge(vanilla|forge)?\b`)},
+	{"Velocity", regexp.MustCompile(`(?i)\bvelocity\b`)},
+	{"Waterfall", regexp.MustCompile(`(?i)\b(waterfall|flamecord)\b`)},
+	{"BungeeCord", regexp.MustCompile(`(?i)\bbungee(cord)?\b`)},
+	{"Geyser", regexp.MustCompile(`(?i)\bgeyser(mc)?\b`)},
+	{"Limbo", regexp.MustCompile(`(?i)\b(nano)?limbo(api)?\b`)},
+}
+
+// vanillaVersion es el formato que envía un servidor sin modificar
+// ("1.20.4", "24w14a"); Fabric y Forge lo conservan, así que solo es un indicio.
+var vanillaVersion = regexp.MustCompile(`^(\d+\.\d+(\.\d+)?(-(pre|rc)\d+)?|\d{2}w\d{2}[a-z])$`)
+
+// fingerprint acumula indicios por implementación. Los pesos de indicios
+// independientes se combinan como 1 - Π(1 - w).
+type fingerprint map[string]float64
+
+func (f fingerprint) add(software string, weight float64) {
+	f[software] = 1 - (1-f[software])*(1-weight)
+}
+
+func (f fingerprint) addNames(text string, weight float64) {
+	if text == "" {
+		return
+	}
+	for _, rule := range nameRules {
+		if rule.pattern.MatchString(text) {
+			f.add(rule.software, weight)
+			return
+		}
+	}
+}
+
+func (f fingerprint) best() (string, float64) {
+	software, confidence := "", 0.0
+	for _, name := range knownSoftware {
+		if f[name] > confidence {
+			software, confidence = name, f[name]
+		}
+	}
+	return software, confidence
+}
+
+// FingerprintSoftware deduce la implementación del servidor a partir de lo
+// que ya se ha recogido: versión, campos extra del status (puede ser nil),
+// cargador de mods, resultado del login (puede ser nil) y datos de Query.
+// Si nada coincide se usa el server_mod de Query tal cual con confianza 0.5.
+func FingerprintSoftware(detail *ServerDetail, status *StatusResponse, login *LoginProbe) (string, float64) {
+	f := fingerprint{}
+
+	if detail.Edition == EditionBedrock {
+		f.addNames(detail.MOTD, 0.6)
+		f.addNames(detail.LevelName, 0.6)
+		if detail.MOTD == "Dedicated Server" || detail.LevelName == "Bedrock level" {
+			f.add("Bedrock Dedicated Server", 0.6)
+		}
+		return f.best()
+	}
+
+	f.addNames(detail.VersionName, 0.9)
+	if vanillaVersion.MatchString(detail.VersionName) {
+		f.add("Vanilla", 0.4)
+	}
+
+	switch detail.ModLoader {
+	case ModLoaderNeoForge:
+		f.add("NeoForge", 0.95)
+	case ModLoaderForge, ModLoaderFML:
+		f.add("Forge", 0.95)
+	}
+	// NoChatReports es sobre todo un mod de Fabric, pero también existe
+	// como plugin (FreedomChat), así que pesa poco.
+	if status != nil && status.PreventsChatReports && detail.ModLoader == "" {
+		f.add("Fabric", 0.3)
+	}
+
+	// Query: server_mod o el prefijo de "plugins" ("Paper on 1.20.4: ...")
+	if detail.QueryKV != nil {
+		f.addNames(detail.QueryKV["server_mod"], 0.9)
+		plugins := detail.QueryKV["plugins"]
+		if software, _, ok := strings.Cut(plugins, " on "); ok {
+			f.addNames(software, 0.9)
+		} else if plugins == "" && vanillaVersion.MatchString(detail.VersionName) {
+			// El vanilla responde a Query sin plugins ni server_mod
+			f.add("Vanilla", 0.3)
+		}
+	}
+
+	if login != nil {
+		for _, ch := range login.Channels {
+			switch {
+			case strings.HasPrefix(ch, "fml:"), strings.HasPrefix(ch, "forge:"):
+				f.add("Forge", 0.8)
+			case strings.HasPrefix(ch, "neoforge:"):
+				f.add("NeoForge", 0.8)
+			case ch == "velocity:player_info":
+				// Backend con modern forwarding: Paper o un fork suyo
+				f.add("Paper", 0.5)
+			}
+		}
+		// Spigot rechaza así las conexiones directas cuando espera BungeeCord
+		if strings.Contains(login.Reason.PlainText(), "BungeeCord config") {
+			f.add("Spigot", 0.5)
+		}
+	}
+
+	if software, confidence := f.best(); software != "" {
+		return software, confidence
+	}
+	if mod := detail.QueryKV["server_mod"]; mod != "" {
+		return mod, 0.5
+	}
+	return "", 0
+}
@@ -0,0 +1,216 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Cargadores de mods que se distinguen en el Server List Ping.
+const (
+	ModLoaderFML      = "fml"      // 1.7 - 1.12, modinfo.modList
+	ModLoaderForge    = "forge"    // 1.13+, forgeData
+	ModLoaderNeoForge = "neoforge" // fork de Forge desde 1.20.1
+)
+
+// ModInfo es el bloque que FML añadía al status hasta 1.12.
+type ModInfo struct {
+	Type    string `json:"type"`
+	ModList []struct {
+		ModID   string `json:"modid"`
+		Version string `json:"version"`
+	} `json:"modList"`
+}
+
+// ForgeData es el bloque forgeData de Forge 1.13+. Desde 1.18 los mods y
+// canales viajan codificados en D y las listas en claro llegan vacías.
+type ForgeData struct {
+	Channels          []ForgeChannel `json:"channels"`
+	Mods              []ForgeMod     `json:"mods"`
+	FMLNetworkVersion int            `json:"fmlNetworkVersion"`
+	Truncated         bool           `json:"truncated"`
+	D                 string         `json:"d"`
+}
+
+// ForgeMod admite las dos formas de la versión: "modmarker" en FML2/FML3 y
+// "version" en las implementaciones que imitan el formato antiguo.
+type ForgeMod struct {
+	ModID     string `json:"modId"`
+	ModMarker string `json:"modmarker"`
+	Version   string `json:"version"`
+}
+
+func (m ForgeMod) version() string {
+	if m.ModMarker != "" {
+		return m.ModMarker
+	}
+	return m.Version
+}
+
+// ForgeChannel es un canal de red registrado por un mod.
+type ForgeChannel struct {
+	Name     string `json:"res"`
+	Version  string `json:"version"`
+	Required bool   `json:"required"`
+}
+
+// forgeServerOnly empieza el marcador de versión de los mods que solo
+// existen en el servidor (IExtensionPoint.DisplayTest.IGNORESERVERONLY).
+const forgeServerOnly = "OHNOES"
+
+// DecodeForgeData decodifica el campo forgeData.d de FML3. Forge empaqueta
+// los bytes en caracteres de 15 bits (los dos primeros llevan la longitud) y
+// el contenido es: truncated, número de mods (short), cada mod con sus
+// canales, y los canales que no pertenecen a ningún mod.
+func DecodeForgeData(d string) (*ForgeData, error) {
+	raw, err := unpackForgeString(d)
+	if err != nil {
+		return nil, err
+	}
+	r := bytes.NewReader(raw)
+	fd := &ForgeData{}
+
+	truncated, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	fd.Truncated = truncated != 0
+
+	var modCount uint16
+	if err := binary.Read(r, binary.BigEndian, &modCount); err != nil {
+		return nil, err
+	}
+	for i := 0; i < int(modCount); i++ {
+		flags, err := ReadVarIntSafe(r)
+		if err != nil {
+			return nil, err
+		}
+		modID, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		mod := ForgeMod{ModID: modID}
+		if flags&1 == 0 {
+			if mod.ModMarker, err = readString(r); err != nil {
+				return nil, err
+			}
+		}
+		fd.Mods = append(fd.Mods, mod)
+
+		// Los canales de un mod solo llevan la ruta; el namespace es el modid
+		for c := 0; c < flags>>1; c++ {
+			ch, err := readForgeChannel(r)
+			if err != nil {
+				return nil, err
+			}
+			ch.Name = modID + ":" + ch.Name
+			fd.Channels = append(fd.Channels, ch)
+		}
+	}
+
+	channelCount, err := ReadVarIntSafe(r)
+	if err != nil {
+		return nil, err
+	}
+	for c := 0; c < channelCount; c++ {
+		ch, err := readForgeChannel(r)
+		if err != nil {
+			return nil, err
+		}
+		fd.Channels = append(fd.Channels, ch)
+	}
+	return fd, nil
+}
+
+func readForgeChannel(r *bytes.Reader) (ForgeChannel, error) {
+	var ch ForgeChannel
+	var err error
+	if ch.Name, err = readString(r); err != nil {
+		return ch, err
+	}
+	if ch.Version, err = readString(r); err != nil {
+		return ch, err
+	}
+	required, err := r.ReadByte()
+	ch.Required = required != 0
+	return ch, err
+}
+
+// unpackForgeString invierte ServerStatusPing.encodeOptimized: cada carácter
+// aporta 15 bits, en little endian, hasta completar la longitud indicada.
+func unpackForgeString(s string) ([]byte, error) {
+	chars := []rune(s)
+	if len(chars) < 2 {
+		return nil, errors.New("forgeData.d too short")
+	}
+	size := int(chars[0]&0x7FFF) | int(chars[1]&0x7FFF)<<15
+	if size > len(chars)*2 {
+		return nil, fmt.Errorf("forgeData.d declares %d bytes in %d chars", size, len(chars))
+	}
+
+	out := make([]byte, 0, size)
+	var buffer uint32
+	bits := 0
+	for _, c := range chars[2:] {
+		for bits >= 8 && len(out) < size {
+			out = append(out, byte(buffer))
+			buffer >>= 8
+			bits -= 8
+		}
+		buffer |= uint32(c&0x7FFF) << bits
+		bits += 15
+	}
+	for bits >= 8 && len(out) < size {
+		out = append(out, byte(buffer))
+		buffer >>= 8
+		bits -= 8
+	}
+	if len(out) < size {
+		return nil, fmt.Errorf("forgeData.d truncated: %d of %d bytes", len(out), size)
+	}
+	return out, nil
+}
+
+// applyModData rellena los mods, canales y el cargador a partir de
+// cualquiera de los formatos: modinfo (FML), forgeData en claro (FML2) o
+// codificado en d (FML3).
+func applyModData(detail *ServerDetail, status *StatusResponse) {
+	if mi := status.ModInfo; mi != nil && strings.EqualFold(mi.Type, "FML") {
+		detail.ModLoader = ModLoaderFML
+		detail.FMLNetworkVersion = 1
+		for _, m := range mi.ModList {
+			detail.Mods[m.ModID] = m.Version
+		}
+	}
+
+	if fd := status.ForgeData; fd != nil {
+		detail.ModLoader = ModLoaderForge
+		detail.FMLNetworkVersion = fd.FMLNetworkVersion
+		detail.ModsTruncated = fd.Truncated
+		mods, channels := fd.Mods, fd.Channels
+		if fd.D != "" {
+			if decoded, err := DecodeForgeData(fd.D); err == nil {
+				mods = append(mods, decoded.Mods...)
+				channels = append(channels, decoded.Channels...)
+				detail.ModsTruncated = detail.ModsTruncated || decoded.Truncated
+			}
+		}
+		for _, m := range mods {
+			version := m.version()
+			if strings.HasPrefix(version, forgeServerOnly) {
+				version = ""
+			}
+			detail.Mods[m.ModID] = version
+		}
+		detail.ModChannels = channels
+	}
+
+	// NeoForge se anuncia como mod propio o, sin forgeData, con isModded
+	_, neo := detail.Mods["neoforge"]
+	if neo || strings.Contains(strings.ToLower(detail.VersionName), "neoforge") ||
+		(status.IsModded && status.ForgeData == nil) {
+		detail.ModLoader = ModLoaderNeoForge
+	}
+}
@@ -0,0 +1,120 @@
+package protocol
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// KickCategory clasifica el motivo de un kick durante el login.
+type KickCategory string
+
+const (
+	KickNone           KickCategory = ""
+	KickWhitelist      KickCategory = "whitelist"
+	KickBanned         KickCategory = "banned"
+	KickMaintenance    KickCategory = "maintenance"
+	KickOutdatedClient KickCategory = "outdated_client"
+	KickModdedRequired KickCategory = "modded_required"
+	KickVPNBlocked     KickCategory = "vpn_blocked"
+	KickFull           KickCategory = "full"
+	KickProxyRequired  KickCategory = "proxy_required"
+	// KickOther es un mensaje que no coincide con ninguna regla.
+	KickOther KickCategory = "other"
+)
+
+var kickCategories = map[KickCategory]bool{
+	KickWhitelist: true, KickBanned: true, KickMaintenance: true, KickOutdatedClient: true,
+	KickModdedRequired: true, KickVPNBlocked: true, KickFull: true, KickProxyRequired: true,
+}
+
+// LoginOutcome traduce la categoría al resultado de login que se guarda en
+// login_outcome; las que no cambian el modo del servidor quedan en kicked.
+func (c KickCategory) LoginOutcome() LoginOutcome {
+	switch c {
+	case KickWhitelist:
+		return LoginWhitelisted
+	case KickBanned:
+		return LoginBanned
+	case KickModdedRequired:
+		return LoginModdedRequired
+	case KickProxyRequired:
+		return LoginProxyRejected
+	default:
+		return LoginKicked
+	}
+}
+
+type KickRule struct {
+	Category KickCategory
+	Pattern  *regexp.Regexp
+}
+
+//go:embed kick_rules.txt
+var builtinKickRules string
+
+// BuiltinKickRules son las reglas incluidas en el binario, en varios idiomas.
+func BuiltinKickRules() []KickRule {
+	rules, err := ParseKickRules(strings.NewReader(builtinKickRules))
+	if err != nil {
+		panic(fmt.Sprintf("kick_rules.txt: %v", err))
+	}
+	return rules
+}
+
+// ParseKickRules lee un fichero de reglas con el formato de kick_rules.txt.
+func ParseKickRules(r io.Reader) ([]KickRule, error) {
+	var rules []KickRule
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.IndexAny(line, " \t")
+		if i < 0 || strings.TrimSpace(line[i:]) == "" {
+			return nil, fmt.Errorf("line %d: expected \"category regexp\"", n)
+		}
+		category, expr := line[:i], strings.TrimSpace(line[i:])
+		if !kickCategories[KickCategory(category)] {
+			return nil, fmt.Errorf("line %d: unknown category %q", n, category)
+		}
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		rules = append(rules, KickRule{Category: KickCategory(category), Pattern: re})
+	}
+	return rules, sc.Err()
+}
+
+// KickClassifier aplica las reglas en orden; gana la primera que coincide.
+type KickClassifier struct {
+	rules []KickRule
+}
+
+func NewKickClassifier(rules []KickRule) *KickClassifier {
+	return &KickClassifier{rules: rules}
+}
+
+// Classify devuelve la categoría del texto plano de un mensaje de desconexión.
+func (c *KickClassifier) Classify(text string) KickCategory {
+	for _, rule := range c.rules {
+		if rule.Pattern.MatchString(text) {
+			return rule.Category
+		}
+	}
+	return KickOther
+}
+
+// DefaultKickClassifier es el que usa ProbeLogin. El comando scan lo
+// sustituye cuando se pasa --kick-rules.
+var DefaultKickClassifier = NewKickClassifier(BuiltinKickRules())
+
+// ClassifyKick clasifica un mensaje de desconexión con DefaultKickClassifier.
+func ClassifyKick(reason ChatComponent) KickCategory {
+	return DefaultKickClassifier.Classify(reason.PlainText())
+}
@@ -0,0 +1,178 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// LegacyPingVariant elige qué petición del Server List Ping anterior a 1.7 se envía.
+type LegacyPingVariant int
+
+const (
+	// LegacyPingBeta es el 0xFE a secas que entienden Beta 1.8 a 1.3.
+	LegacyPingBeta LegacyPingVariant = iota
+	// LegacyPing14 es 0xFE 0x01, desde 1.4.
+	LegacyPing14
+	// LegacyPing16 añade el plugin message MC|PingHost que envía el cliente 1.6.
+	LegacyPing16
+)
+
+// legacyPingProtocol es el protocolo que anuncia un cliente 1.6.4 en MC|PingHost.
+const legacyPingProtocol = 78
+
+type LegacyStatus struct {
+	Protocol      int
+	VersionName   string
+	MOTD          string
+	PlayersOnline int
+	PlayersMax    int
+}
+
+// GetLegacyStatus hace el ping de un servidor anterior a 1.7 empezando por el
+// formato más reciente y, si no contesta con un kick, repite con los antiguos
+// en otra conexión. Un timeout corta la serie: el puerto no va a responder; y
+// una respuesta que no es un kick 0xFF también: ahí no hay un Minecraft.
+func GetLegacyStatus(host string, port int, timeout time.Duration) (*LegacyStatus, error) {
+	return getLegacyStatus(host, port, host, timeout)
+}
+
+// getLegacyStatus es GetLegacyStatus anunciando hsHost en MC|PingHost.
+func getLegacyStatus(ip string, port int, hsHost string, timeout time.Duration) (*LegacyStatus, error) {
+	var lastErr error
+	for _, variant := range []LegacyPingVariant{LegacyPing16, LegacyPing14, LegacyPingBeta} {
+		status, err := legacyPing(ip, port, hsHost, timeout, variant)
+		if err == nil {
+			return status, nil
+		}
+		lastErr = err
+		var netErr net.Error
+		if (errors.As(err, &netErr) && netErr.Timeout()) || errors.Is(err, ErrNotDetected) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+func LegacyPing(host string, port int, timeout time.Duration, variant LegacyPingVariant) (*LegacyStatus, error) {
+	return legacyPing(host, port, host, timeout, variant)
+}
+
+func legacyPing(ip string, port int, hsHost string, timeout time.Duration, variant LegacyPingVariant) (*LegacyStatus, error) {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := conn.Write(buildLegacyPing(hsHost, port, variant)); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 3)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	if header[0] != 0xFF {
+		return nil, fmt.Errorf("%w: unexpected legacy packet id 0x%02x", ErrNotDetected, header[0])
+	}
+
+	length := int(binary.BigEndian.Uint16(header[1:]))
+	payload := make([]byte, length*2)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return nil, err
+	}
+
+	return ParseLegacyResponse(decodeUTF16BE(payload))
+}
+
+func buildLegacyPing(host string, port int, variant LegacyPingVariant) []byte {
+	buf := new(bytes.Buffer)
+	_ = buf.WriteByte(0xFE)
+	if variant == LegacyPingBeta {
+		return buf.Bytes()
+	}
+	_ = buf.WriteByte(0x01)
+	if variant == LegacyPing14 {
+		return buf.Bytes()
+	}
+
+	channel := encodeUTF16BE("MC|PingHost")
+	hostname := encodeUTF16BE(host)
+
+	_ = buf.WriteByte(0xFA)
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(channel)/2))
+	_, _ = buf.Write(channel)
+	_ = binary.Write(buf, binary.BigEndian, uint16(7+len(hostname)))
+	_ = buf.WriteByte(legacyPingProtocol)
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(hostname)/2))
+	_, _ = buf.Write(hostname)
+	_ = binary.Write(buf, binary.BigEndian, int32(port))
+	return buf.Bytes()
+}
+
+// ParseLegacyResponse decodifica el texto del kick 0xFF. Desde 1.4 el formato
+// es "§1\x00protocolo\x00versión\x00motd\x00online\x00max"; antes, "motd§online§max".
+func ParseLegacyResponse(s string) (*LegacyStatus, error) {
+	if strings.HasPrefix(s, "§1\x00") {
+		fields := strings.Split(s, "\x00")
+		if len(fields) < 6 {
+			return nil, fmt.Errorf("malformed legacy response")
+		}
+		proto, _ := strconv.Atoi(fields[1])
+		online, _ := strconv.Atoi(fields[4])
+		maxPlayers, _ := strconv.Atoi(fields[5])
+		return &LegacyStatus{
+			Protocol:      proto,
+			VersionName:   fields[2],
+			MOTD:          fields[3],
+			PlayersOnline: online,
+			PlayersMax:    maxPlayers,
+		}, nil
+	}
+
+	// El MOTD puede llevar códigos de color con §: los contadores se toman del final
+	fields := strings.Split(s, "§")
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("malformed legacy response")
+	}
+	online, err := strconv.Atoi(fields[len(fields)-2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed legacy response: %w", err)
+	}
+	maxPlayers, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed legacy response: %w", err)
+	}
+	return &LegacyStatus{
+		VersionName:   "Beta 1.8-1.3",
+		MOTD:          strings.Join(fields[:len(fields)-2], "§"),
+		PlayersOnline: online,
+		PlayersMax:    maxPlayers,
+	}, nil
+}
+
+func encodeUTF16BE(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	out := make([]byte, len(units)*2)
+	for i, u := range units {
+		binary.BigEndian.PutUint16(out[i*2:], u)
+	}
+	return out
+}
+
+func decodeUTF16BE(b []byte) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.BigEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,233 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoginOutcome resume cómo responde el servidor a un Login Start de una
+// cuenta no premium. Se guarda tal cual en la columna login_outcome.
+type LoginOutcome string
+
+const (
+	LoginUnknown        LoginOutcome = ""
+	LoginOnline         LoginOutcome = "online"
+	LoginOffline        LoginOutcome = "offline"
+	LoginWhitelisted    LoginOutcome = "whitelisted"
+	LoginBanned         LoginOutcome = "banned"
+	LoginModdedRequired LoginOutcome = "modded_required"
+	LoginProxyRejected  LoginOutcome = "proxy_rejected"
+	// LoginKicked es un kick que no encaja en ninguna otra categoría
+	// (servidor lleno, versión incorrecta, mensajes propios de plugins...).
+	LoginKicked LoginOutcome = "kicked"
+)
+
+// LoginOutcomes enumera los valores válidos en el orden en que se documentan.
+var LoginOutcomes = []LoginOutcome{
+	LoginOnline, LoginOffline, LoginWhitelisted, LoginBanned,
+	LoginModdedRequired, LoginProxyRejected, LoginKicked,
+}
+
+// Paquetes clientbound del estado login.
+const (
+	loginDisconnect     = 0x00
+	loginEncryption     = 0x01
+	loginSuccess        = 0x02
+	loginSetCompression = 0x03
+	loginPluginRequest  = 0x04
+)
+
+// loginPluginResponse es el ID serverbound de Login Plugin Response.
+const loginPluginResponse = 0x02
+
+// maxLoginPackets limita los paquetes intermedios (Login Plugin Request, Set
+// Compression) que aceptamos; un servidor normal decide en dos o tres.
+const maxLoginPackets = 8
+
+// probeUsername es el nombre con el que se intenta entrar.
+const probeUsername = "GeminiCrawler"
+
+var probeUUID = []byte{0xDE, 0xAD, 0xBE, 0xEF, 0xDE, 0xAD, 0xBE, 0xEF, 0xDE, 0xAD, 0xBE, 0xEF, 0xDE, 0xAD, 0xBE, 0xEF}
+
+// LoginProbe es el resultado de ProbeLogin.
+type LoginProbe struct {
+	Outcome LoginOutcome
+	// Reason es el mensaje de desconexión cuando el servidor nos expulsa.
+	Reason ChatComponent
+	// Kick es la categoría del mensaje según DefaultKickClassifier.
+	Kick KickCategory
+	// Channels son los canales de los Login Plugin Request recibidos.
+	Channels []string
+}
+
+// ProbeLogin inicia sesión con el protocolo que anuncia el servidor y
+// clasifica la primera respuesta definitiva. Nunca se completa el login: una
+// Encryption Request o un Login Success bastan para saber el modo.
+func ProbeLogin(ip string, port int, protocolVersion int, timeout time.Duration) (*LoginProbe, error) {
+	return probeLogin(ip, port, ip, protocolVersion, timeout)
+}
+
+// probeLogin es ProbeLogin anunciando hsHost en el handshake.
+func probeLogin(ip string, port int, hsHost string, protocolVersion int, timeout time.Duration) (*LoginProbe, error) {
+	// En mantenimiento o con algunos plugins el status anuncia protocol -1; se
+	// entra con el mismo protocolo que el ping de estado
+	if protocolVersion <= 0 {
+		protocolVersion = statusProtocol
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := sendHandshake(conn, hsHost, port, protocolVersion, 2); err != nil {
+		return nil, err
+	}
+	pc := NewPacketConn(conn)
+	if err := pc.WritePacket(loginStart(protocolVersion)); err != nil {
+		return nil, err
+	}
+
+	probe := &LoginProbe{}
+	for i := 0; i < maxLoginPackets; i++ {
+		id, body, err := pc.ReadPacket()
+		if err != nil {
+			// Tras un Login Plugin Request sin respuesta válida algunos backends
+			// cortan sin mensaje; el canal basta para clasificarlos.
+			if byChannel := outcomeFromChannels(probe.Channels); byChannel != LoginUnknown {
+				probe.Outcome = byChannel
+				return probe, nil
+			}
+			return probe, err
+		}
+
+		switch id {
+		case loginDisconnect:
+			reason, err := readString(body)
+			if err != nil {
+				return probe, err
+			}
+			var raw interface{}
+			if json.Unmarshal([]byte(reason), &raw) != nil {
+				raw = reason
+			}
+			probe.Reason = ParseChat(raw)
+			probe.Kick = ClassifyKick(probe.Reason)
+			probe.Outcome = probe.Kick.LoginOutcome()
+			if probe.Outcome == LoginKicked {
+				if byChannel := outcomeFromChannels(probe.Channels); byChannel != LoginUnknown {
+					probe.Outcome = byChannel
+				}
+			}
+			return probe, nil
+		case loginEncryption:
+			probe.Outcome = LoginOnline
+			if protocolVersion >= 766 && !shouldAuthenticate(body) {
+				// 1.20.5+ puede cifrar sin validar la cuenta contra Mojang
+				probe.Outcome = LoginOffline
+			}
+			return probe, nil
+		case loginSuccess:
+			probe.Outcome = LoginOffline
+			return probe, nil
+		case loginSetCompression:
+			// Lo que venga después (Login Success o un kick) llega comprimido
+			threshold, err := ReadVarIntSafe(body)
+			if err != nil {
+				return probe, err
+			}
+			pc.SetCompression(threshold)
+		case loginPluginRequest:
+			messageID, err := ReadVarIntSafe(body)
+			if err != nil {
+				return probe, err
+			}
+			channel, err := readString(body)
+			if err != nil {
+				return probe, err
+			}
+			probe.Channels = append(probe.Channels, channel)
+
+			// Respondemos "no entendido", como un cliente vanilla
+			resp := new(bytes.Buffer)
+			_ = WriteVarInt(resp, loginPluginResponse)
+			_ = WriteVarInt(resp, messageID)
+			_ = resp.WriteByte(0x00)
+			if err := pc.WritePacket(resp.Bytes()); err != nil {
+				return probe, err
+			}
+		default:
+			return probe, fmt.Errorf("unexpected login packet 0x%02x", id)
+		}
+	}
+	return probe, errors.New("too many login plugin requests")
+}
+
+// loginStart construye el Login Start según la versión: desde 1.19 lleva el
+// UUID del jugador, precedido de un booleano hasta 1.20.1.
+func loginStart(protocolVersion int) []byte {
+	buf := new(bytes.Buffer)
+	_ = WriteVarInt(buf, 0x00)
+	_ = WriteVarInt(buf, len(probeUsername))
+	_, _ = buf.WriteString(probeUsername)
+	if protocolVersion >= 764 {
+		_, _ = buf.Write(probeUUID)
+	} else if protocolVersion >= 759 {
+		_ = buf.WriteByte(0x01)
+		_, _ = buf.Write(probeUUID)
+	}
+	return buf.Bytes()
+}
+
+// shouldAuthenticate lee el último campo de la Encryption Request de 1.20.5+.
+// Si el paquete no lo trae se asume true, que es lo que hace el cliente.
+func shouldAuthenticate(body *bytes.Reader) bool {
+	if _, err := readString(body); err != nil { // ID del servidor
+		return true
+	}
+	for i := 0; i < 2; i++ { // clave pública y verify token
+		n, err := ReadVarIntSafe(body)
+		if err != nil || n < 0 || int64(n) > int64(body.Len()) {
+			return true
+		}
+		_, _ = body.Seek(int64(n), io.SeekCurrent)
+	}
+	b, err := body.ReadByte()
+	return err != nil || b != 0x00
+}
+
+// outcomeFromChannels reconoce los handshakes de login de Velocity y Forge.
+func outcomeFromChannels(channels []string) LoginOutcome {
+	for _, ch := range channels {
+		switch {
+		case ch == "velocity:player_info":
+			return LoginProxyRejected
+		case strings.HasPrefix(ch, "fml:"), strings.HasPrefix(ch, "forge:"):
+			return LoginModdedRequired
+		}
+	}
+	return LoginUnknown
+}
+
+func readString(r io.Reader) (string, error) {
+	n, err := ReadVarIntSafe(r)
+	if err != nil {
+		return "", err
+	}
+	if n < 0 || n > maxPacketLength {
+		return "", fmt.Errorf("invalid string length %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,114 @@
+package protocol
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// maxPacketLength es el tamaño máximo de trama que admite el protocolo (2^21 - 1).
+const maxPacketLength = 1<<21 - 1
+
+// maxUncompressedLength es el límite del cliente vanilla para un paquete
+// descomprimido (2^23).
+const maxUncompressedLength = 1 << 23
+
+// PacketConn lee y escribe paquetes Java tras el handshake. Hasta que se
+// llama a SetCompression las tramas son longitud + ID + datos; después cada
+// trama lleva además la longitud descomprimida (0 si va tal cual) y los
+// datos en zlib cuando superan el umbral.
+type PacketConn struct {
+	rw        io.ReadWriter
+	threshold int
+}
+
+func NewPacketConn(rw io.ReadWriter) *PacketConn {
+	return &PacketConn{rw: rw, threshold: -1}
+}
+
+// SetCompression aplica el umbral recibido en Set Compression. Un valor
+// negativo la desactiva, igual que en el servidor.
+func (c *PacketConn) SetCompression(threshold int) {
+	c.threshold = threshold
+}
+
+// ReadPacket devuelve el ID y el resto del cuerpo del siguiente paquete.
+func (c *PacketConn) ReadPacket() (int, *bytes.Reader, error) {
+	length, err := ReadVarIntSafe(c.rw)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length <= 0 || length > maxPacketLength {
+		return 0, nil, fmt.Errorf("%w: packet length %d", errInvalidFrame, length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, data); err != nil {
+		return 0, nil, err
+	}
+
+	payload := bytes.NewReader(data)
+	if c.threshold >= 0 {
+		if payload, err = decompress(payload); err != nil {
+			return 0, nil, err
+		}
+	}
+	id, err := ReadVarIntSafe(payload)
+	if err != nil {
+		return 0, nil, err
+	}
+	return id, payload, nil
+}
+
+func decompress(frame *bytes.Reader) (*bytes.Reader, error) {
+	dataLength, err := ReadVarIntSafe(frame)
+	if err != nil {
+		return nil, err
+	}
+	if dataLength == 0 {
+		return frame, nil
+	}
+	if dataLength < 0 || dataLength > maxUncompressedLength {
+		return nil, fmt.Errorf("invalid uncompressed length %d", dataLength)
+	}
+
+	zr, err := zlib.NewReader(frame)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data := make([]byte, dataLength)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, fmt.Errorf("bad compressed packet: %w", err)
+	}
+	return bytes.NewReader(data), nil
+}
+
+// WritePacket envía payload (ID + datos). Con compresión activa los paquetes
+// por debajo del umbral van sin comprimir, como hace el cliente.
+func (c *PacketConn) WritePacket(payload []byte) error {
+	frame := new(bytes.Buffer)
+	if c.threshold < 0 {
+		_ = WriteVarInt(frame, len(payload))
+		_, _ = frame.Write(payload)
+		_, err := c.rw.Write(frame.Bytes())
+		return err
+	}
+
+	inner := new(bytes.Buffer)
+	if len(payload) < c.threshold {
+		_ = WriteVarInt(inner, 0)
+		_, _ = inner.Write(payload)
+	} else {
+		_ = WriteVarInt(inner, len(payload))
+		zw := zlib.NewWriter(inner)
+		_, _ = zw.Write(payload)
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+	_ = WriteVarInt(frame, inner.Len())
+	_, _ = frame.Write(inner.Bytes())
+	_, err := c.rw.Write(frame.Bytes())
+	return err
+}
@@ -0,0 +1,152 @@
+package protocol
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tipos de proxy que se distinguen por el nombre de versión. ProxyUnknown
+// es un endpoint que se comporta como proxy (enruta por hostname) sin
+// identificarse.
+const (
+	ProxyVelocity   = "velocity"
+	ProxyBungeeCord = "bungeecord"
+	ProxyWaterfall  = "waterfall"
+	ProxyUnknown    = "proxy"
+)
+
+// statusProtocol es el protocolo que se anuncia en el ping de estado (1.20.1).
+const statusProtocol = 763
+
+// proxyProbeProtocols son versiones de referencia, de menor a mayor, con
+// las que se acota el rango que acepta un endpoint multiprotocolo.
+var proxyProbeProtocols = []int{
+	4,   // 1.7.2
+	47,  // 1.8
+	107, // 1.9
+	315, // 1.11
+	340, // 1.12.2
+	393, // 1.13
+	498, // 1.14.4
+	578, // 1.15.2
+	754, // 1.16.5
+	756, // 1.17.1
+	758, // 1.18.2
+	760, // 1.19.2
+	763, // 1.20.1
+	765, // 1.20.4
+	767, // 1.21
+	769, // 1.21.4
+	771, // 1.21.6
+}
+
+// proxyProbeHost es un hostname que ningún servidor tiene configurado: un
+// proxy con forced hosts responde con su servidor por defecto, y los que
+// exigen entrar por dominio responden con otro MOTD.
+const proxyProbeHost = "mccrawler.invalid"
+
+var proxyNames = []struct {
+	proxy   string
+	pattern *regexp.Regexp
+}{
+	{ProxyVelocity, regexp.MustCompile(`(?i)\bvelocity\b`)},
+	{ProxyWaterfall, regexp.MustCompile(`(?i)\b(waterfall|flamecord|travertine)\b`)},
+	{ProxyBungeeCord, regexp.MustCompile(`(?i)\bbungee(cord)?\b`)},
+}
+
+// ProxyInfo resume lo que se sabe de un endpoint que acepta varias versiones.
+// Un rango sin Type es un servidor multiprotocolo (ViaVersion), no un proxy.
+type ProxyInfo struct {
+	Type        string
+	ProtocolMin int
+	ProtocolMax int
+	// HostRouting indica que la respuesta cambia según el hostname del handshake.
+	HostRouting bool
+}
+
+// ProbeProxy decide si el endpoint es un proxy a partir del status ya
+// leído y, si hay indicios, repite el ping con otros protocolos y otro
+// hostname. Un servidor normal contesta siempre con su propio protocolo,
+// mientras que BungeeCord, Velocity (y ViaVersion) devuelven el del cliente
+// si lo soportan. Un servidor que no lo devuelve cuesta cero conexiones
+// extra; uno de 1.20.1 sin proxy, dos.
+func ProbeProxy(ip string, port int, baseline *StatusResponse, timeout time.Duration) *ProxyInfo {
+	return probeProxy(ip, port, ip, baseline, timeout)
+}
+
+// probeProxy es ProbeProxy para un baseline obtenido anunciando hsHost.
+func probeProxy(ip string, port int, hsHost string, baseline *StatusResponse, timeout time.Duration) *ProxyInfo {
+	info := &ProxyInfo{}
+	for _, p := range proxyNames {
+		if p.pattern.MatchString(baseline.Version.Name) {
+			info.Type = p.proxy
+			break
+		}
+	}
+
+	echoes := func(i int) bool {
+		p := proxyProbeProtocols[i]
+		status, err := getStatus(ip, port, hsHost, p, timeout)
+		return err == nil && status.Version.Protocol == p
+	}
+
+	// Un protocolo devuelto distinto del enviado confirma el eco; con 1.20.1
+	// el baseline no basta porque puede ser la versión real del servidor
+	anchor := -1
+	if baseline.Version.Protocol == statusProtocol {
+		// 1.20.4 y 1.19.2: vecinas que cualquier proxy actual acepta
+		for _, p := range []int{765, 760} {
+			if i := slices.Index(proxyProbeProtocols, p); echoes(i) {
+				anchor = i
+				break
+			}
+		}
+	}
+	if anchor < 0 && info.Type == "" {
+		return nil
+	}
+
+	if anchor >= 0 {
+		// Las versiones soportadas forman un rango continuo de la lista
+		lo := sort.Search(anchor, echoes)
+		hi := anchor + 1 + sort.Search(len(proxyProbeProtocols)-anchor-1, func(i int) bool {
+			return !echoes(anchor + 1 + i)
+		})
+		info.ProtocolMin = proxyProbeProtocols[lo]
+		info.ProtocolMax = proxyProbeProtocols[hi-1]
+	}
+
+	if other, err := getStatus(ip, port, proxyProbeHost, statusProtocol, timeout); err == nil {
+		info.HostRouting = statusKey(other) != statusKey(baseline)
+	}
+	if info.Type == "" && info.HostRouting {
+		info.Type = ProxyUnknown
+	}
+	return info
+}
+
+// statusKey reduce una respuesta a lo que distingue un backend de otro.
+func statusKey(s *StatusResponse) string {
+	return strings.Join([]string{s.Version.Name, ParseChat(s.Description).PlainText(), s.Favicon}, "\x00")
+}
+
+// applyProxyInfo guarda el resultado de ProbeProxy y marca los servidores
+// con Geyser (Bedrock) instalado como plugin, que Query lista.
+func applyProxyInfo(detail *ServerDetail, info *ProxyInfo) {
+	if info != nil {
+		detail.ProxyType = info.Type
+		detail.ProtocolMin = info.ProtocolMin
+		detail.ProtocolMax = info.ProtocolMax
+		detail.HostRouting = info.HostRouting
+	}
+	for _, p := range detail.Plugins {
+		name := strings.ToLower(p)
+		if strings.HasPrefix(name, "geyser") || strings.HasPrefix(name, "floodgate") {
+			detail.GeyserHybrid = true
+			break
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -14,15 +15,25 @@ type QueryResult struct {
 	Plugins  []string          `json:"plugins"`
 	MapName  string            `json:"map_name"`
 	RawKV    map[string]string `json:"raw_kv"`
+	Players  []string          `json:"players"`
 }
 
+var (
+	// Relleno fijo que precede a las claves en una respuesta full stat
+	querySplitnum = []byte("splitnum\x00\x80\x00")
+	// Marcador que separa las claves de la lista de jugadores
+	queryPlayerMarker = []byte("\x01player_\x00\x00")
+)
+
 func GetQueryInfo(ip string, port int, timeout time.Duration) (*QueryResult, error) {
-	addr := fmt.Sprintf("%s:%d", ip, port)
+	addr := net.JoinHostPort(ip, strconv.Itoa(port))
 	conn, err := net.DialTimeout("udp", addr, timeout)
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Close()
+	// Sin plazo un UDP filtrado dejaría el worker bloqueado para siempre
+	_ = conn.SetDeadline(time.Now().Add(timeout))
 
 	sessionId := int32(0x01010101 & 0x0F0F0F0F)
 	
@@ -32,13 +43,13 @@ func GetQueryInfo(ip string, port int, timeout time.Duration) (*QueryResult, err
 	
 	_, _ = conn.Write(handshake.Bytes())
 	
-	resp := make([]byte, 2048)
+	resp := make([]byte, 4096)
 	n, err := conn.Read(resp)
-	if err != nil || n < 5 {
+	if err != nil || n < 6 {
 		return nil, fmt.Errorf("no query response")
 	}
 
-	tokenStr := string(resp[5 : n-1])
+	tokenStr, _, _ := strings.Cut(string(resp[5:n]), "\x00")
 	var token int32
 	if _, err := fmt.Sscanf(tokenStr, "%d", &token); err != nil {
 		return nil, err
@@ -52,27 +63,52 @@ func GetQueryInfo(ip string, port int, timeout time.Duration) (*QueryResult, err
 
 	_, _ = conn.Write(statReq.Bytes())
 	n, err = conn.Read(resp)
-	if err != nil || n < 11 {
+	if err != nil {
+		return nil, fmt.Errorf("stat request failed: %w", err)
+	}
+	return ParseFullStat(resp[:n])
+}
+
+// ParseFullStat decodifica una respuesta full stat: tipo e ID de sesión, el
+// relleno "splitnum", pares clave/valor separados por NUL que acaban en una
+// clave vacía, el marcador player_ y un nombre terminado en NUL por jugador.
+func ParseFullStat(resp []byte) (*QueryResult, error) {
+	if len(resp) < 5 || resp[0] != 0x00 {
 		return nil, fmt.Errorf("stat request failed")
 	}
+	data := bytes.TrimPrefix(resp[5:], querySplitnum)
 
-	data := resp[11:n]
-	kvData := bytes.Split(data, []byte{0x00, 0x01, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x00, 0x00})
-	
 	kvPairs := make(map[string]string)
-	parts := bytes.Split(kvData[0], []byte{0x00})
-	for i := 0; i < len(parts)-1; i += 2 {
-		key := string(parts[i])
-		if key == "" { break }
-		val := string(parts[i+1])
+	for len(data) > 0 {
+		key, rest := cutNul(data)
+		data = rest
+		if key == "" {
+			break
+		}
+		val, rest := cutNul(data)
+		data = rest
 		kvPairs[key] = val
 	}
 
+	players := []string{}
+	if i := bytes.Index(data, queryPlayerMarker); i >= 0 {
+		data = data[i+len(queryPlayerMarker):]
+		for len(data) > 0 {
+			name, rest := cutNul(data)
+			data = rest
+			if name == "" {
+				break
+			}
+			players = append(players, name)
+		}
+	}
+
 	result := &QueryResult{
 		Software: kvPairs["server_mod"],
 		MapName:  kvPairs["map"],
 		RawKV:    kvPairs,
 		Plugins:  []string{},
+		Players:  players,
 	}
 
 	if p, ok := kvPairs["plugins"]; ok {
@@ -86,4 +122,10 @@ func GetQueryInfo(ip string, port int, timeout time.Duration) (*QueryResult, err
 	}
 
 	return result, nil
-}
\ No newline at end of file
+}
+
+// cutNul devuelve la cadena hasta el siguiente NUL y el resto tras él.
+func cutNul(b []byte) (string, []byte) {
+	s, rest, _ := bytes.Cut(b, []byte{0x00})
+	return string(s), rest
+}
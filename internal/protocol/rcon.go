@@ -0,0 +1,129 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Tipos de paquete de Source RCON. EXECCOMMAND y AUTH_RESPONSE comparten valor;
+// el sentido del paquete los distingue.
+const (
+	RconTypeResponseValue int32 = 0
+	RconTypeExecCommand   int32 = 2
+	RconTypeAuthResponse  int32 = 2
+	RconTypeAuth          int32 = 3
+)
+
+// DefaultRconPort es el puerto por defecto de rcon.port en server.properties.
+const DefaultRconPort = 25575
+
+// rconAuthFailedID es el ID que devuelve el servidor cuando la contraseña no es válida.
+const rconAuthFailedID int32 = -1
+
+// Un paquete mide al menos ID + tipo + dos NUL (10 bytes) y el cuerpo de una
+// respuesta no pasa de 4096 bytes.
+const (
+	rconMinSize = 10
+	rconMaxSize = 4096 + rconMinSize
+)
+
+// rconRequestID identifica nuestras peticiones en las respuestas ("MC").
+const rconRequestID int32 = 0x4d43
+
+type RconPacket struct {
+	ID   int32
+	Type int32
+	Body string
+}
+
+// Encode serializa el paquete: longitud, ID y tipo en little endian, y el
+// cuerpo terminado en dos bytes NUL.
+func (p RconPacket) Encode() []byte {
+	buf := new(bytes.Buffer)
+	_ = binary.Write(buf, binary.LittleEndian, int32(len(p.Body)+rconMinSize))
+	_ = binary.Write(buf, binary.LittleEndian, p.ID)
+	_ = binary.Write(buf, binary.LittleEndian, p.Type)
+	_, _ = buf.WriteString(p.Body)
+	_, _ = buf.Write([]byte{0x00, 0x00})
+	return buf.Bytes()
+}
+
+// ReadRconPacket lee un paquete completo. Una longitud fuera de rango o un
+// cuerpo sin terminar en NUL significan que al otro lado no hay RCON y se
+// devuelven como ErrNotDetected.
+func ReadRconPacket(r io.Reader) (RconPacket, error) {
+	var size int32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return RconPacket{}, err
+	}
+	if size < rconMinSize || size > rconMaxSize {
+		return RconPacket{}, fmt.Errorf("%w: rcon packet size %d", ErrNotDetected, size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return RconPacket{}, err
+	}
+	if data[size-2] != 0x00 || data[size-1] != 0x00 {
+		return RconPacket{}, fmt.Errorf("%w: rcon packet not NUL terminated", ErrNotDetected)
+	}
+
+	return RconPacket{
+		ID:   int32(binary.LittleEndian.Uint32(data[0:4])),
+		Type: int32(binary.LittleEndian.Uint32(data[4:8])),
+		Body: string(data[8 : size-2]),
+	}, nil
+}
+
+// ProbeRcon intenta autenticarse con password. Devuelve si la contraseña fue
+// aceptada; un rechazo (ID -1) no es un error porque confirma que el puerto
+// habla RCON.
+func ProbeRcon(ip string, port int, timeout time.Duration, password string) (bool, error) {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), timeout)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	auth := RconPacket{ID: rconRequestID, Type: RconTypeAuth, Body: password}
+	if _, err := conn.Write(auth.Encode()); err != nil {
+		return false, err
+	}
+
+	// Los servidores Source mandan un RESPONSE_VALUE vacío antes del AUTH_RESPONSE
+	for i := 0; i < 2; i++ {
+		resp, err := ReadRconPacket(conn)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case resp.Type == RconTypeResponseValue && resp.ID == rconRequestID && resp.Body == "":
+			continue
+		case resp.Type == RconTypeAuthResponse && resp.ID == rconRequestID:
+			return true, nil
+		case resp.Type == RconTypeAuthResponse && resp.ID == rconAuthFailedID:
+			return false, nil
+		default:
+			return false, fmt.Errorf("%w: unexpected rcon reply id=%d type=%d", ErrNotDetected, resp.ID, resp.Type)
+		}
+	}
+	return false, fmt.Errorf("%w: no rcon auth response", ErrNotDetected)
+}
+
+// AnalyzeRcon detecta un servicio RCON expuesto intentando autenticarse con
+// una contraseña vacía.
+func AnalyzeRcon(ip string, port int, timeout time.Duration) (*ServerDetail, error) {
+	if _, err := ProbeRcon(ip, port, timeout, ""); err != nil {
+		return nil, err
+	}
+	return &ServerDetail{
+		IP: ip, Port: port, Edition: EditionJava, Timestamp: time.Now(), Mods: make(map[string]string),
+		RconOpen: true, Software: "RCON Service",
+	}, nil
+}
@@ -0,0 +1,101 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrNotDetected indica que el endpoint respondió pero no habla el protocolo
+// del analizador.
+var ErrNotDetected = errors.New("protocol not detected")
+
+// Analyzer reconoce y analiza un protocolo. Analyze debe fallar, sin efectos
+// secundarios, cuando el endpoint no habla su protocolo.
+type Analyzer struct {
+	Name    string
+	Edition string
+	Analyze func(ip string, port int, timeout time.Duration) (*ServerDetail, error)
+	// AnalyzeHost, si no es nil, se usa cuando el endpoint viene de un
+	// hostname para anunciarlo en el handshake.
+	AnalyzeHost func(ip string, port int, hostname string, timeout time.Duration) (*ServerDetail, error)
+	// Ports son los puertos habituales del protocolo; en ellos el analizador
+	// se prueba antes que el resto, aunque también se prueba en cualquier otro.
+	Ports []int
+}
+
+// Registry elige el analizador por lo que responde el endpoint y no por el
+// número de puerto: prueba en orden los analizadores de la edición y devuelve
+// el primer resultado. El puerto sólo adelanta a los analizadores que lo
+// tienen en Ports.
+type Registry struct {
+	analyzers []Analyzer
+}
+
+func NewRegistry(analyzers ...Analyzer) *Registry {
+	return &Registry{analyzers: analyzers}
+}
+
+// Register añade un analizador al final de la lista de prueba.
+func (r *Registry) Register(a Analyzer) {
+	r.analyzers = append(r.analyzers, a)
+}
+
+// Analyze devuelve el resultado del primer analizador que reconoce el
+// endpoint. Si no se puede conectar no se prueba ningún otro.
+func (r *Registry) Analyze(edition string, ip string, port int, timeout time.Duration) (*ServerDetail, error) {
+	return r.AnalyzeHost(edition, ip, port, "", timeout)
+}
+
+// AnalyzeHost es Analyze para un endpoint al que se llegó por hostname: los
+// analizadores que lo admiten lo anuncian y el resultado lo conserva.
+func (r *Registry) AnalyzeHost(edition string, ip string, port int, hostname string, timeout time.Duration) (*ServerDetail, error) {
+	lastErr := fmt.Errorf("no analyzer registered for edition %q", edition)
+	for _, a := range r.candidates(edition, port) {
+		var detail *ServerDetail
+		var err error
+		if hostname != "" && a.AnalyzeHost != nil {
+			detail, err = a.AnalyzeHost(ip, port, hostname, timeout)
+		} else {
+			detail, err = a.Analyze(ip, port, timeout)
+		}
+		if err == nil {
+			if hostname != "" {
+				detail.Hostname = hostname
+			}
+			return detail, nil
+		}
+		if isDialError(err) {
+			return nil, err
+		}
+		lastErr = fmt.Errorf("%s: %w", a.Name, err)
+	}
+	return nil, lastErr
+}
+
+// candidates devuelve los analizadores de la edición con los que tienen port
+// entre sus puertos habituales delante, sin alterar el orden de registro.
+func (r *Registry) candidates(edition string, port int) []Analyzer {
+	var hinted, rest []Analyzer
+	for _, a := range r.analyzers {
+		if a.Edition != edition {
+			continue
+		}
+		if slices.Contains(a.Ports, port) {
+			hinted = append(hinted, a)
+		} else {
+			rest = append(rest, a)
+		}
+	}
+	return append(hinted, rest...)
+}
+
+// DefaultRegistry prueba primero el Server List Ping (con el fallback legacy),
+// después RCON, y para Bedrock el ping RakNet. En el puerto de RCON se empieza
+// por RCON para no esperar antes al ping de estado.
+var DefaultRegistry = NewRegistry(
+	Analyzer{Name: "java", Edition: EditionJava, Analyze: AnalyzeServer, AnalyzeHost: AnalyzeServerHost},
+	Analyzer{Name: "rcon", Edition: EditionJava, Analyze: AnalyzeRcon, Ports: []int{DefaultRconPort}},
+	Analyzer{Name: "bedrock", Edition: EditionBedrock, Analyze: AnalyzeBedrock, Ports: []int{DefaultBedrockPort}},
+)
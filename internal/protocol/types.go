@@ -8,32 +8,61 @@ import (
 type ServerDetail struct {
 	IP                 string            `json:"ip"`
 	Port               int               `json:"port"`
+	Hostname           string            `json:"hostname,omitempty"`
+	Edition            string            `json:"edition"`
 	Timestamp          time.Time         `json:"timestamp"`
 	VersionName        string            `json:"version_name"`
 	Protocol           int               `json:"protocol"`
 	MOTD               string            `json:"motd"`
-	Icon               []byte            `json:"icon"`
+	MOTDJSON           string            `json:"motd_json"`
+	Icon               []byte            `json:"icon,omitempty"`
+	IconHash           string            `json:"icon_hash"`
 	PlayersOnline      int               `json:"players_online"`
 	PlayersMax         int               `json:"players_max"`
 	Software           string            `json:"software"`
+	SoftwareConfidence float64           `json:"software_confidence,omitempty"`
 	Mods               map[string]string `json:"mods"`
 	Plugins            []string          `json:"plugins"`
+	ModLoader          string            `json:"mod_loader,omitempty"`
+	FMLNetworkVersion  int               `json:"fml_network_version,omitempty"`
+	ModChannels        []ForgeChannel    `json:"mod_channels,omitempty"`
+	ModsTruncated      bool              `json:"mods_truncated,omitempty"`
+	ProxyType          string            `json:"proxy_type,omitempty"`
+	ProtocolMin        int               `json:"protocol_min,omitempty"`
+	ProtocolMax        int               `json:"protocol_max,omitempty"`
+	HostRouting        bool              `json:"host_routing,omitempty"`
+	GeyserHybrid       bool              `json:"geyser_hybrid,omitempty"`
 	IsWhitelist        bool              `json:"whitelist"`
 	EnforcesSecureChat bool              `json:"secure_chat"`
 	RconOpen           bool              `json:"rcon_open"`
+	LoginOutcome       LoginOutcome      `json:"login_outcome"`
+	KickReason         string            `json:"kick_reason,omitempty"`
+	KickCategory       KickCategory      `json:"kick_category,omitempty"`
+	ServerGUID         string            `json:"server_guid,omitempty"`
+	LevelName          string            `json:"level_name,omitempty"`
+	GameMode           string            `json:"game_mode,omitempty"`
+	PortV4             int               `json:"port_v4,omitempty"`
+	PortV6             int               `json:"port_v6,omitempty"`
+	QueryKV            map[string]string `json:"query_kv,omitempty"`
+	QueryPlayers       []string          `json:"query_players,omitempty"`
+	PlayerSample       []PlayerSample    `json:"player_sample,omitempty"`
+	VirtualHosts       []VirtualHost     `json:"virtual_hosts,omitempty"`
 }
 
+// WriteVarInt codifica value como un int32 del protocolo: los negativos
+// ocupan cinco bytes en complemento a dos, como en el cliente.
 func WriteVarInt(w io.Writer, value int) error {
+	v := uint32(value)
 	for {
-		if (value & ^0x7F) == 0 {
-			_, err := w.Write([]byte{byte(value)})
+		if (v & ^uint32(0x7F)) == 0 {
+			_, err := w.Write([]byte{byte(v)})
 			return err
 		}
-		_, err := w.Write([]byte{byte((value & 0x7F) | 0x80)})
+		_, err := w.Write([]byte{byte((v & 0x7F) | 0x80)})
 		if err != nil {
 			return err
 		}
-		value >>= 7
+		v >>= 7
 	}
 }
 
@@ -0,0 +1,54 @@
+package protocol
+
+import "time"
+
+// Orígenes de los hostnames con los que se repite el ping.
+const (
+	HostSourceReverseDNS  = "ptr"
+	HostSourceCertificate = "cert"
+	HostSourceSRV         = "srv"
+	HostSourceList        = "list"
+)
+
+// VirtualHost es la respuesta de un endpoint cuando el handshake anuncia un
+// hostname concreto. Las redes que enrutan por dominio (forced hosts) o que
+// solo contestan bien a su dominio tienen un registro por hostname.
+type VirtualHost struct {
+	Hostname      string `json:"hostname"`
+	Source        string `json:"source"`
+	VersionName   string `json:"version_name"`
+	Protocol      int    `json:"protocol"`
+	MOTD          string `json:"motd"`
+	MOTDJSON      string `json:"motd_json"`
+	Icon          []byte `json:"icon,omitempty"`
+	IconHash      string `json:"icon_hash"`
+	PlayersOnline int    `json:"players_online"`
+	PlayersMax    int    `json:"players_max"`
+}
+
+// ProbeVirtualHost hace el ping de estado contra ip:port anunciando hostname
+// en el handshake.
+func ProbeVirtualHost(ip string, port int, hostname string, timeout time.Duration) (*VirtualHost, error) {
+	status, err := getStatus(ip, port, hostname, statusProtocol, timeout)
+	if err != nil {
+		return nil, err
+	}
+	motd := ParseChat(status.Description)
+	vh := &VirtualHost{
+		Hostname:      hostname,
+		VersionName:   status.Version.Name,
+		Protocol:      status.Version.Protocol,
+		MOTD:          motd.PlainText(),
+		MOTDJSON:      motd.JSON(),
+		PlayersOnline: status.Players.Online,
+		PlayersMax:    status.Players.Max,
+	}
+	vh.Icon, vh.IconHash = decodeFavicon(status.Favicon)
+	return vh, nil
+}
+
+// SameStatus indica que el hostname devuelve lo mismo que la IP a secas, es
+// decir, que no es un virtual host distinto.
+func (v *VirtualHost) SameStatus(detail *ServerDetail) bool {
+	return v.VersionName == detail.VersionName && v.MOTD == detail.MOTD && v.IconHash == detail.IconHash
+}
@@ -0,0 +1,180 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultExcludes son los mismos rangos que BuildArguments excluye al barrer 0.0.0.0/0.
+var defaultExcludes = []string{"255.255.255.255/32", "127.0.0.0/8", "0.0.0.0/8", "224.0.0.0/4"}
+
+// ConnectScanner descubre puertos TCP abiertos con connect() normales, sin
+// root ni masscan instalado. Es mucho más lento que masscan y está pensado
+// para rangos pequeños o máquinas sin privilegios.
+type ConnectScanner struct {
+	// Targets son IPs sueltas o rangos CIDR.
+	Targets []string
+	Ports   []int
+	// Concurrency es el número de conexiones simultáneas.
+	Concurrency int
+	// Rate limita las conexiones nuevas por segundo; 0 = sin límite.
+	Rate    int
+	Timeout time.Duration
+	// Exclude son IPs o rangos CIDR que nunca se prueban.
+	Exclude []string
+}
+
+func (c *ConnectScanner) Discover(ctx context.Context, out chan<- Endpoint) error {
+	defer close(out)
+
+	targets, err := ParsePrefixes(c.Targets)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no targets to scan")
+	}
+	excludes := c.Exclude
+	for _, t := range c.Targets {
+		if strings.TrimSpace(t) == "0.0.0.0/0" && len(excludes) == 0 {
+			excludes = defaultExcludes
+		}
+	}
+	excluded, err := ParsePrefixes(excludes)
+	if err != nil {
+		return err
+	}
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+
+	jobs := make(chan Endpoint)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dialer := net.Dialer{Timeout: timeout}
+			for ep := range jobs {
+				conn, err := dialer.DialContext(ctx, "tcp", ep.String())
+				if err != nil {
+					continue
+				}
+				conn.Close()
+				send(ctx, out, ep)
+			}
+		}()
+	}
+
+	var limiter <-chan time.Time
+	if c.Rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(c.Rate))
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	enqueue := func(ep Endpoint) bool {
+		if limiter != nil {
+			select {
+			case <-limiter:
+			case <-ctx.Done():
+				return false
+			}
+		}
+		select {
+		case jobs <- ep:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for _, prefix := range targets {
+		ok := forEachAddr(prefix, func(addr netip.Addr) bool {
+			if containsAddr(excluded, addr) {
+				return true
+			}
+			for _, port := range c.Ports {
+				if !enqueue(Endpoint{IP: addr.String(), Port: port}) {
+					return false
+				}
+			}
+			return true
+		})
+		if !ok {
+			break
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+	return nil
+}
+
+// ParsePrefixes interpreta IPs y rangos CIDR; una IP suelta se convierte en
+// un /32 o /128.
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid range %q: %w", v, err)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", v, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// LoadExcludeFile lee un fichero de exclusiones al estilo de masscan: una IP
+// o rango CIDR por línea, '#' inicia un comentario.
+func LoadExcludeFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	return entries, sc.Err()
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
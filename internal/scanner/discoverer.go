@@ -0,0 +1,37 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"strconv"
+)
+
+// Endpoint es un host:puerto candidato que el descubrimiento pasa a los analizadores.
+// Hostname solo se rellena si el endpoint viene de un nombre de dominio y es
+// lo que los analizadores anuncian en el handshake.
+type Endpoint struct {
+	IP       string
+	Port     int
+	Hostname string
+}
+
+func (e Endpoint) String() string {
+	return net.JoinHostPort(e.IP, strconv.Itoa(e.Port))
+}
+
+// Discoverer envía endpoints candidatos al pipeline. Discover bloquea hasta
+// que termina el descubrimiento o se cancela ctx, y siempre cierra out antes
+// de volver.
+type Discoverer interface {
+	Discover(ctx context.Context, out chan<- Endpoint) error
+}
+
+// send entrega ep salvo que ctx se cancele antes.
+func send(ctx context.Context, out chan<- Endpoint, ep Endpoint) bool {
+	select {
+	case out <- ep:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,248 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Formatos de fichero de objetivos aceptados por NewTargetSource.
+const (
+	FormatAuto        = "auto"
+	FormatList        = "list"
+	FormatMasscanJSON = "masscan-json"
+	FormatMasscanList = "masscan-list"
+	FormatMasscanXML  = "masscan-xml"
+	FormatNmapXML     = "nmap-xml"
+	FormatZmapCSV     = "zmap-csv"
+)
+
+// NewTargetSource devuelve un Discoverer que lee los objetivos de r en el
+// formato indicado. Con FormatAuto el formato se deduce de las primeras líneas.
+// ports se usa con los objetivos que no indican puerto.
+func NewTargetSource(format string, r io.Reader, ports []int) (Discoverer, error) {
+	br := bufio.NewReader(r)
+	if format == FormatAuto || format == "" {
+		format = sniffFormat(br)
+	}
+
+	var parse parseFunc
+	switch format {
+	case FormatList:
+		return &TargetList{Reader: br, Ports: ports}, nil
+	case FormatMasscanJSON:
+		parse = parseMasscanJSON
+	case FormatMasscanList:
+		parse = parseMasscanList
+	case FormatMasscanXML, FormatNmapXML:
+		// masscan -oX imita el XML de nmap, así que comparten parser
+		parse = parseNmapXML
+	case FormatZmapCSV:
+		parse = parseZmapCSV
+	default:
+		return nil, fmt.Errorf("formato de objetivos no soportado: %s", format)
+	}
+	return &importer{r: br, ports: ports, parse: parse}, nil
+}
+
+// parseFunc lee r y llama a emit por cada endpoint; emit devuelve false si
+// hay que parar.
+type parseFunc func(r io.Reader, ports []int, emit func(Endpoint) bool) error
+
+// importer recorre la salida guardada de otra herramienta como si fuera un
+// descubrimiento.
+type importer struct {
+	r     io.Reader
+	ports []int
+	parse parseFunc
+}
+
+func (i *importer) Discover(ctx context.Context, out chan<- Endpoint) error {
+	defer close(out)
+	return i.parse(i.r, i.ports, func(ep Endpoint) bool {
+		return send(ctx, out, ep)
+	})
+}
+
+func sniffFormat(br *bufio.Reader) string {
+	head, _ := br.Peek(4096)
+	head = bytes.TrimLeft(head, " \t\r\n\xef\xbb\xbf")
+
+	switch {
+	case len(head) == 0:
+		return FormatList
+	case head[0] == '<':
+		return FormatNmapXML
+	case head[0] == '[' || head[0] == '{':
+		return FormatMasscanJSON
+	}
+
+	first, _, _ := bytes.Cut(head, []byte("\n"))
+	first = bytes.TrimSpace(first)
+	switch {
+	case bytes.HasPrefix(first, []byte("#masscan")) || bytes.HasPrefix(first, []byte("open ")):
+		return FormatMasscanList
+	case bytes.HasPrefix(first, []byte("saddr")):
+		return FormatZmapCSV
+	}
+	return FormatList
+}
+
+// parseMasscanJSON lee la salida de masscan -oJ: una línea JSON por host.
+func parseMasscanJSON(r io.Reader, _ []int, emit func(Endpoint) bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		res, ok := ParseMasscanLine(sc.Bytes())
+		if !ok {
+			continue
+		}
+		for _, p := range res.Ports {
+			if !emit(Endpoint{IP: res.IP, Port: p.Port}) {
+				return nil
+			}
+		}
+	}
+	return sc.Err()
+}
+
+// parseMasscanList lee la salida de masscan -oL ("open tcp 25565 1.2.3.4 1700000000").
+// Las líneas de banner se ignoran.
+func parseMasscanList(r io.Reader, _ []int, emit func(Endpoint) bool) error {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 4 || fields[0] != "open" {
+			continue
+		}
+		port, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+		addr, err := netip.ParseAddr(fields[3])
+		if err != nil {
+			continue
+		}
+		if !emit(Endpoint{IP: addr.String(), Port: port}) {
+			return nil
+		}
+	}
+	return sc.Err()
+}
+
+type nmapHost struct {
+	Addresses []struct {
+		Addr string `xml:"addr,attr"`
+		Type string `xml:"addrtype,attr"`
+	} `xml:"address"`
+	Ports []struct {
+		PortID int `xml:"portid,attr"`
+		State  struct {
+			State string `xml:"state,attr"`
+		} `xml:"state"`
+	} `xml:"ports>port"`
+}
+
+// parseNmapXML lee nmap -oX y masscan -oX host a host, sin cargar el
+// documento entero. Solo se envían los puertos en estado "open".
+func parseNmapXML(r io.Reader, _ []int, emit func(Endpoint) bool) error {
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "host" {
+			continue
+		}
+
+		var host nmapHost
+		if err := dec.DecodeElement(&host, &start); err != nil {
+			return err
+		}
+		for _, a := range host.Addresses {
+			if a.Type != "ipv4" && a.Type != "ipv6" {
+				continue
+			}
+			for _, p := range host.Ports {
+				if p.State.State != "open" {
+					continue
+				}
+				if !emit(Endpoint{IP: a.Addr, Port: p.PortID}) {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// parseZmapCSV lee la salida CSV de zmap. Con cabecera se usan las columnas
+// saddr y sport (y success si existe); sin ella la primera columna es la IP
+// y se usan los puertos de ports, como en la salida por defecto de zmap.
+func parseZmapCSV(r io.Reader, ports []int, emit func(Endpoint) bool) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+
+	ipCol, portCol, successCol := 0, -1, -1
+	first := true
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if first {
+			first = false
+			if indexOf(rec, "saddr") >= 0 {
+				ipCol, portCol, successCol = indexOf(rec, "saddr"), indexOf(rec, "sport"), indexOf(rec, "success")
+				continue
+			}
+		}
+
+		if ipCol >= len(rec) {
+			continue
+		}
+		if successCol >= 0 && successCol < len(rec) && (rec[successCol] == "0" || rec[successCol] == "false") {
+			continue
+		}
+		addr, err := netip.ParseAddr(strings.TrimSpace(rec[ipCol]))
+		if err != nil {
+			continue
+		}
+		recPorts := ports
+		if portCol >= 0 && portCol < len(rec) {
+			if n, err := strconv.Atoi(strings.TrimSpace(rec[portCol])); err == nil {
+				recPorts = []int{n}
+			}
+		}
+		for _, port := range recPorts {
+			if !emit(Endpoint{IP: addr.String(), Port: port}) {
+				return nil
+			}
+		}
+	}
+}
+
+func indexOf(fields []string, name string) int {
+	for i, f := range fields {
+		if strings.TrimSpace(f) == name {
+			return i
+		}
+	}
+	return -1
+}
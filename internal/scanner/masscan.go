@@ -1,25 +1,48 @@
 package scanner
 
 import (
+	"MinecraftCrawler/internal/protocol"
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os" // Importante para os.Stderr
 	"os/exec"
+	"strings"
 )
 
 type MasscanResult struct {
 	IP    string `json:"ip"`
 	Ports []struct {
-		Port int `json:"port"`
+		Port  int    `json:"port"`
+		Proto string `json:"proto"`
 	} `json:"ports"`
 }
 
 // BuildArguments constructs the arguments for masscan
 func BuildArguments(ipRange string, rate string, port int, excludeFile string) []string {
+	return BuildPortsArguments(ipRange, rate, []int{port}, excludeFile)
+}
+
+// BuildPortsArguments construye los argumentos de un barrido TCP de varios
+// puertos; los consecutivos se agrupan en rangos.
+func BuildPortsArguments(ipRange string, rate string, ports []int, excludeFile string) []string {
+	return buildArguments(ipRange, rate, FormatPorts(ports, ""), excludeFile)
+}
+
+// BuildUDPArguments construye los argumentos de un barrido UDP. Masscan solo
+// recibe respuesta si envía un payload válido, que se carga de payloadFile
+// (formato nmap-payloads).
+func BuildUDPArguments(ipRange string, rate string, ports []int, excludeFile string, payloadFile string) []string {
+	args := buildArguments(ipRange, rate, FormatPorts(ports, "U:"), excludeFile)
+	return append(args, "--nmap-payloads", payloadFile)
+}
+
+func buildArguments(ipRange string, rate string, ports string, excludeFile string) []string {
 	args := []string{
 		ipRange,
-		"-p", fmt.Sprintf("%d", port),
+		"-p", ports,
 		"--rate", rate,
 		"-oJ", "-",
 	}
@@ -32,10 +55,71 @@ func BuildArguments(ipRange string, rate string, port int, excludeFile string) [
 	return args
 }
 
-func Run(ipRange string, rate string, port int, excludeFile string, ipChan chan<- string) error {
-	args := BuildArguments(ipRange, rate, port, excludeFile)
+// Masscan descubre endpoints ejecutando el binario masscan (requiere root).
+// Con Bedrock se barre el puerto UDP con un RakNet Unconnected Ping para que
+// solo respondan los hosts que hablan como un servidor Bedrock.
+type Masscan struct {
+	Range       string
+	Rate        string
+	Ports       []int
+	ExcludeFile string
+	Bedrock     bool
+}
+
+// Discover lanza masscan y envía cada endpoint abierto a out hasta que
+// masscan termina. Cancelar ctx detiene masscan.
+func (m *Masscan) Discover(ctx context.Context, out chan<- Endpoint) error {
+	defer close(out)
 
-	cmd := exec.Command("masscan", args...)
+	args := BuildPortsArguments(m.Range, m.Rate, m.Ports, m.ExcludeFile)
+	if m.Bedrock {
+		payloadFile, err := writeUDPPayload(m.Ports, protocol.BedrockPingPacket(0))
+		if err != nil {
+			return err
+		}
+		defer os.Remove(payloadFile)
+		args = BuildUDPArguments(m.Range, m.Rate, m.Ports, m.ExcludeFile, payloadFile)
+	}
+	return run(ctx, args, out)
+}
+
+func writeUDPPayload(ports []int, payload []byte) (string, error) {
+	f, err := os.CreateTemp("", "mccrawler-payload-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var escaped strings.Builder
+	for _, b := range payload {
+		fmt.Fprintf(&escaped, "\\x%02x", b)
+	}
+	if _, err := fmt.Fprintf(f, "udp %s \"%s\"\n", FormatPorts(ports, ""), escaped.String()); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// ParseMasscanLine decodifica una línea de la salida -oJ de masscan. Tolera
+// los corchetes y las comas finales de la lista JSON.
+func ParseMasscanLine(line []byte) (MasscanResult, bool) {
+	var res MasscanResult
+	line = bytes.TrimSpace(line)
+	if len(line) < 10 || line[0] == '[' || line[0] == ']' {
+		return res, false
+	}
+	if line[len(line)-1] == ',' {
+		line = line[:len(line)-1]
+	}
+	if err := json.Unmarshal(line, &res); err != nil || len(res.Ports) == 0 {
+		return res, false
+	}
+	return res, true
+}
+
+func run(ctx context.Context, args []string, out chan<- Endpoint) error {
+	cmd := exec.CommandContext(ctx, "masscan", args...)
 
 	
 	// Redirigimos el stderr de masscan al stderr de nuestro programa 
@@ -51,27 +135,27 @@ func Run(ipRange string, rate string, port int, excludeFile string, ipChan chan<
 		return err
 	}
 
-	go func() {
-		defer close(ipChan)
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			line := scanner.Bytes()
-			if len(line) < 10 || line[0] == '[' || line[0] == ']' {
-				continue
-			}
-			if line[len(line)-1] == ',' {
-				line = line[:len(line)-1]
-			}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		// Tras cancelar seguimos leyendo hasta EOF para no bloquear a masscan
+		if ctx.Err() != nil {
+			continue
+		}
 
-			var res MasscanResult
-			if err := json.Unmarshal(line, &res); err == nil {
-				if len(res.Ports) > 0 {
-					ipChan <- res.IP
-				}
+		res, ok := ParseMasscanLine(scanner.Bytes())
+		if !ok {
+			continue
+		}
+		for _, p := range res.Ports {
+			if !send(ctx, out, Endpoint{IP: res.IP, Port: p.Port}) {
+				break
 			}
 		}
-		_ = cmd.Wait()
-	}()
+	}
 
+	// Un masscan interrumpido por la señal no es un error del escaneo
+	if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+		return err
+	}
 	return nil
 }
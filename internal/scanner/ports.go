@@ -0,0 +1,77 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParsePorts interpreta una lista de puertos y rangos como "25565-25575,19132".
+// Los duplicados se eliminan y se conserva el orden de aparición.
+func ParsePorts(spec string) ([]int, error) {
+	var ports []int
+	seen := make(map[int]bool)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		lo, hi, isRange := strings.Cut(item, "-")
+		first, err := parsePort(lo)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parsePort(hi); err != nil {
+				return nil, err
+			}
+			if last < first {
+				return nil, fmt.Errorf("rango de puertos invertido: %s", item)
+			}
+		}
+
+		for p := first; p <= last; p++ {
+			if !seen[p] {
+				seen[p] = true
+				ports = append(ports, p)
+			}
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("lista de puertos vacía")
+	}
+	return ports, nil
+}
+
+func parsePort(s string) (int, error) {
+	p, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || p < 1 || p > 65535 {
+		return 0, fmt.Errorf("puerto inválido: %q", s)
+	}
+	return p, nil
+}
+
+// FormatPorts compacta una lista de puertos en rangos ("25565-25575,19132")
+// anteponiendo prefix a cada elemento ("U:" para los puertos UDP de masscan).
+func FormatPorts(ports []int, prefix string) string {
+	sorted := append([]int(nil), ports...)
+	sort.Ints(sorted)
+
+	var items []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] <= sorted[j]+1 {
+			j++
+		}
+		if sorted[i] == sorted[j] {
+			items = append(items, prefix+strconv.Itoa(sorted[i]))
+		} else {
+			items = append(items, fmt.Sprintf("%s%d-%d", prefix, sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,103 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Resolver es lo que el escáner necesita del DNS. *net.Resolver lo cumple;
+// los tests usan uno falso con registros fijos.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+// DefaultResolver lo usan TargetList y los demás consumidores que no tienen
+// uno propio. scan --dns lo sustituye.
+var DefaultResolver Resolver = net.DefaultResolver
+
+// NewResolver consulta directamente a server ("1.1.1.1" o "[2606:4700::1111]:53")
+// en lugar de a los servidores del sistema.
+func NewResolver(server string) Resolver {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: 5 * time.Second}
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// ResolveHost convierte un hostname en endpoints como lo hace el cliente:
+// sin puerto se consulta primero _minecraft._tcp.host y, si no hay registro
+// SRV, se usan las direcciones A/AAAA del host con defaultPorts. Con puerto
+// explícito no se mira el SRV. Como el cliente, el handshake lleva el destino
+// del SRV si lo hay y host en caso contrario.
+func ResolveHost(ctx context.Context, r Resolver, host string, port int, defaultPorts []int) ([]Endpoint, error) {
+	if r == nil {
+		r = DefaultResolver
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	var eps []Endpoint
+	seen := make(map[Endpoint]bool)
+	add := func(addrs []string, ports []int, hostname string) {
+		for _, addr := range addrs {
+			if a, err := netip.ParseAddr(addr); err == nil {
+				addr = a.Unmap().String()
+			}
+			for _, p := range ports {
+				// Si dos destinos SRV llevan a la misma IP, el primero da el hostname
+				key := Endpoint{IP: addr, Port: p}
+				if !seen[key] {
+					seen[key] = true
+					eps = append(eps, Endpoint{IP: addr, Port: p, Hostname: hostname})
+				}
+			}
+		}
+	}
+
+	if port == 0 {
+		// Un dominio sin SRV devuelve error; no es un fallo, solo se pasa a A/AAAA
+		if _, records, err := r.LookupSRV(ctx, "minecraft", "tcp", host); err == nil {
+			var lastErr error
+			for _, srv := range records {
+				// Un destino "." indica que el servicio no existe (RFC 2782)
+				target := strings.TrimSuffix(srv.Target, ".")
+				if target == "" {
+					continue
+				}
+				addrs, err := r.LookupHost(ctx, target)
+				if err != nil {
+					lastErr = err
+					continue
+				}
+				add(addrs, []int{int(srv.Port)}, target)
+			}
+			if len(eps) > 0 {
+				return eps, nil
+			}
+			if lastErr != nil {
+				return nil, lastErr
+			}
+		}
+	}
+
+	addrs, err := r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ports := defaultPorts
+	if port != 0 {
+		ports = []int{port}
+	}
+	add(addrs, ports, host)
+	return eps, nil
+}
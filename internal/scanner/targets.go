@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// TargetList no descubre nada: lee los objetivos de una lista de texto, uno
+// por línea ('#' inicia un comentario). Acepta IPs, rangos CIDR y nombres de
+// host, con o sin ":puerto"; sin puerto se prueban todos los de Ports. Los
+// nombres se resuelven con ResolveHost (SRV y A/AAAA) y se envía un endpoint
+// por cada dirección, con el nombre para el handshake.
+type TargetList struct {
+	Reader io.Reader
+	Ports  []int
+	// Resolver resuelve los nombres de host; nil usa DefaultResolver.
+	Resolver Resolver
+}
+
+func (t *TargetList) Discover(ctx context.Context, out chan<- Endpoint) error {
+	defer close(out)
+
+	sc := bufio.NewScanner(t.Reader)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		target, err := ParseTarget(line)
+		if err != nil {
+			log.Printf("[!] Línea %d ignorada: %v", lineNo, err)
+			continue
+		}
+		ports := t.Ports
+		if target.Port != 0 {
+			ports = []int{target.Port}
+		}
+
+		var ok bool
+		switch {
+		case target.Prefix.IsValid():
+			ok = forEachAddr(target.Prefix, func(addr netip.Addr) bool {
+				for _, port := range ports {
+					if !send(ctx, out, Endpoint{IP: addr.String(), Port: port}) {
+						return false
+					}
+				}
+				return true
+			})
+		default:
+			eps, err := ResolveHost(ctx, t.Resolver, target.Host, target.Port, t.Ports)
+			if err != nil {
+				log.Printf("[!] Línea %d: no se pudo resolver %s: %v", lineNo, target.Host, err)
+				continue
+			}
+			ok = true
+			for _, ep := range eps {
+				if ok = send(ctx, out, ep); !ok {
+					break
+				}
+			}
+		}
+		if !ok {
+			return nil
+		}
+	}
+	return sc.Err()
+}
+
+// Target es una línea de una lista de objetivos: un rango (una IP suelta es
+// un /32 o /128) o un nombre de host pendiente de resolver. Port es 0 si la
+// línea no indica puerto.
+type Target struct {
+	Prefix netip.Prefix
+	Host   string
+	Port   int
+}
+
+// ParseTarget interpreta "ip", "cidr", "host" con o sin ":puerto" (las IPv6
+// con puerto van entre corchetes).
+func ParseTarget(s string) (Target, error) {
+	host, port := s, 0
+	if _, err := netip.ParseAddr(s); err != nil && !isBareIPv6Prefix(s) {
+		if h, p, err := net.SplitHostPort(s); err == nil {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 1 || n > 65535 {
+				return Target{}, fmt.Errorf("puerto inválido en %q", s)
+			}
+			host, port = h, n
+		}
+	}
+
+	if strings.Contains(host, "/") {
+		prefix, err := netip.ParsePrefix(host)
+		if err != nil {
+			return Target{}, fmt.Errorf("rango inválido %q", host)
+		}
+		return Target{Prefix: prefix.Masked(), Port: port}, nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return Target{Prefix: netip.PrefixFrom(addr, addr.BitLen()), Port: port}, nil
+	}
+	if !validHostname(host) {
+		return Target{}, fmt.Errorf("objetivo inválido %q", s)
+	}
+	return Target{Host: strings.TrimSuffix(host, "."), Port: port}, nil
+}
+
+// isBareIPv6Prefix detecta "2001:db8::/64", que SplitHostPort partiría mal.
+func isBareIPv6Prefix(s string) bool {
+	return !strings.HasPrefix(s, "[") && strings.Count(s, ":") > 1 && strings.Contains(s, "/")
+}
+
+func validHostname(h string) bool {
+	h = strings.TrimSuffix(h, ".")
+	if h == "" || len(h) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(h, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// forEachAddr recorre las direcciones del rango sin materializarlo y para en
+// cuanto fn devuelve false.
+func forEachAddr(prefix netip.Prefix, fn func(netip.Addr) bool) bool {
+	for addr := prefix.Masked().Addr(); addr.IsValid() && prefix.Contains(addr); addr = addr.Next() {
+		if !fn(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+// dialect agrupa lo que cambia entre motores al migrar y consultar
+type dialect struct {
+	dir string
+	// placeholder devuelve el marcador del parámetro n (empezando en 1)
+	placeholder func(n int) string
+	// tableExists cuenta las tablas con el nombre del parámetro 1 sin crear nada
+	tableExists string
+	// migrationLock, si no está vacío, serializa las migraciones de varios
+	// procesos; se ejecuta al empezar cada transacción y se libera al terminarla
+	migrationLock string
+}
+
+// migrationLockKey identifica el advisory lock de las migraciones en PostgreSQL.
+const migrationLockKey = 0x6d6363726177
+
+var sqliteDialect = dialect{
+	dir:         "migrations/sqlite",
+	placeholder: func(int) string { return "?" },
+	tableExists: `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`,
+}
+
+var postgresDialect = dialect{
+	dir:           "migrations/postgres",
+	placeholder:   func(n int) string { return "$" + strconv.Itoa(n) },
+	tableExists:   `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1`,
+	migrationLock: fmt.Sprintf(`SELECT pg_advisory_xact_lock(%d)`, migrationLockKey),
+}
+
+// Migration es un paso del esquema. Los ficheros se llaman NNNN_nombre.sql y
+// se aplican en orden ascendente de versión.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		prefix, rest, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		body, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: rest, SQL: string(body)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// Migrations devuelve las migraciones SQLite embebidas en el binario.
+func Migrations() ([]Migration, error) {
+	return sqliteDialect.migrations()
+}
+
+func (d dialect) migrations() ([]Migration, error) {
+	return loadMigrations(migrationFiles, d.dir)
+}
+
+// LatestVersion es la versión de esquema que deja Migrate.
+func LatestVersion() (int, error) {
+	return sqliteDialect.latestVersion()
+}
+
+func (d dialect) latestVersion() (int, error) {
+	migrations, err := d.migrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// SchemaVersion devuelve la versión aplicada en la base de datos, 0 si nunca se migró.
+func SchemaVersion(db *sql.DB) (int, error) {
+	return schemaVersion(context.Background(), db, sqliteDialect)
+}
+
+// schemaVersion solo lee: una base sin schema_version está en la versión 0 y
+// únicamente migrate crea la tabla.
+func schemaVersion(ctx context.Context, db *sql.DB, d dialect) (int, error) {
+	var tables int
+	if err := db.QueryRowContext(ctx, d.tableExists, "schema_version").Scan(&tables); err != nil {
+		return 0, err
+	}
+	if tables == 0 {
+		return 0, nil
+	}
+	var version sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_version`).Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// beginMigration abre una transacción con el lock de migraciones del dialecto.
+func beginMigration(ctx context.Context, db *sql.DB, d dialect) (*sql.Tx, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if d.migrationLock != "" {
+		if _, err := tx.ExecContext(ctx, d.migrationLock); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// ensureSchemaVersionTable va bajo el lock: en PostgreSQL dos CREATE TABLE IF
+// NOT EXISTS simultáneos pueden chocar en el catálogo.
+func ensureSchemaVersionTable(ctx context.Context, db *sql.DB, d dialect) error {
+	tx, err := beginMigration(ctx, db, d)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			name TEXT,
+			applied_at TIMESTAMP
+		)`); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Migrate aplica las migraciones pendientes y devuelve cuántas se ejecutaron.
+func Migrate(db *sql.DB) (int, error) {
+	return migrate(context.Background(), db, sqliteDialect)
+}
+
+func migrate(ctx context.Context, db *sql.DB, d dialect) (int, error) {
+	migrations, err := d.migrations()
+	if err != nil {
+		return 0, err
+	}
+	if err := ensureSchemaVersionTable(ctx, db, d); err != nil {
+		return 0, err
+	}
+	current, err := schemaVersion(ctx, db, d)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		ok, err := applyMigration(ctx, db, d, m)
+		if err != nil {
+			return applied, fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		if ok {
+			applied++
+		}
+	}
+	return applied, nil
+}
+
+// applyMigration aplica m salvo que otro proceso lo haya hecho mientras se
+// esperaba el lock; por eso la versión se vuelve a leer dentro de la transacción.
+func applyMigration(ctx context.Context, db *sql.DB, d dialect, m Migration) (bool, error) {
+	tx, err := beginMigration(ctx, db, d)
+	if err != nil {
+		return false, err
+	}
+	var current sql.NullInt64
+	if err := tx.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_version`).Scan(&current); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	if int(current.Int64) >= m.Version {
+		return false, tx.Rollback()
+	}
+
+	for _, stmt := range splitStatements(m.SQL) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			_ = tx.Rollback()
+			return false, err
+		}
+	}
+
+	insert := fmt.Sprintf(`INSERT INTO schema_version (version, name, applied_at) VALUES (%s, %s, %s)`,
+		d.placeholder(1), d.placeholder(2), d.placeholder(3))
+	if _, err := tx.ExecContext(ctx, insert, m.Version, m.Name, time.Now()); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// splitStatements separa un fichero de migración en sentencias. Los ficheros
+// no usan triggers ni literales con ';', así que basta con cortar por ';'.
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var stmts []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
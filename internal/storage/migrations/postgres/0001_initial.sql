@@ -0,0 +1,44 @@
+-- Esquema equivalente a la versión 5 de SQLite
+CREATE TABLE IF NOT EXISTS servers (
+	ip TEXT NOT NULL,
+	port INTEGER NOT NULL,
+	edition TEXT,
+	version_name TEXT,
+	protocol INTEGER,
+	motd TEXT,
+	motd_json TEXT,
+	icon_hash TEXT,
+	players_online INTEGER,
+	players_max INTEGER,
+	whitelist BOOLEAN,
+	software TEXT,
+	mods TEXT,
+	plugins TEXT,
+	secure_chat BOOLEAN,
+	server_guid TEXT,
+	level_name TEXT,
+	game_mode TEXT,
+	port_v4 INTEGER,
+	port_v6 INTEGER,
+	timestamp TIMESTAMPTZ,
+	first_seen TIMESTAMPTZ,
+	last_seen TIMESTAMPTZ,
+	UNIQUE(ip, port)
+);
+CREATE TABLE IF NOT EXISTS icons (
+	hash TEXT PRIMARY KEY,
+	data BYTEA
+);
+CREATE TABLE IF NOT EXISTS observations (
+	id BIGSERIAL PRIMARY KEY,
+	ip TEXT,
+	port INTEGER,
+	timestamp TIMESTAMPTZ,
+	version_name TEXT,
+	protocol INTEGER,
+	players_online INTEGER,
+	players_max INTEGER,
+	motd_hash TEXT,
+	whitelist BOOLEAN
+);
+CREATE INDEX IF NOT EXISTS idx_observations_server ON observations (ip, port, timestamp);
@@ -0,0 +1 @@
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS rcon_open BOOLEAN;
@@ -0,0 +1,2 @@
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS query_kv TEXT;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS query_players TEXT;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS player_sightings (
+	id BIGSERIAL PRIMARY KEY,
+	uuid TEXT,
+	name TEXT,
+	ip TEXT,
+	port INTEGER,
+	source TEXT,
+	timestamp TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_player_sightings_uuid ON player_sightings (uuid);
+CREATE INDEX IF NOT EXISTS idx_player_sightings_name ON player_sightings (LOWER(name));
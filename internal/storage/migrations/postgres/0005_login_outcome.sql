@@ -0,0 +1,2 @@
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS login_outcome TEXT;
+CREATE INDEX IF NOT EXISTS idx_servers_login_outcome ON servers (login_outcome);
@@ -0,0 +1,2 @@
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS kick_reason TEXT;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS kick_category TEXT;
@@ -0,0 +1,4 @@
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS mod_loader TEXT;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS fml_network_version INTEGER;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS mod_channels TEXT;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS mods_truncated BOOLEAN;
@@ -0,0 +1,2 @@
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS software_confidence DOUBLE PRECISION;
+CREATE INDEX IF NOT EXISTS idx_servers_software ON servers (software);
@@ -0,0 +1,5 @@
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS proxy_type TEXT;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS protocol_min INTEGER;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS protocol_max INTEGER;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS host_routing BOOLEAN;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS geyser_hybrid BOOLEAN;
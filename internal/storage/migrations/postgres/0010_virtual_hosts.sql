@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS virtual_hosts (
+	ip TEXT NOT NULL,
+	port INTEGER NOT NULL,
+	hostname TEXT NOT NULL,
+	source TEXT,
+	version_name TEXT,
+	protocol INTEGER,
+	motd TEXT,
+	motd_json TEXT,
+	icon_hash TEXT,
+	players_online INTEGER,
+	players_max INTEGER,
+	first_seen TIMESTAMPTZ,
+	last_seen TIMESTAMPTZ,
+	UNIQUE(ip, port, hostname)
+);
+CREATE INDEX IF NOT EXISTS idx_virtual_hosts_hostname ON virtual_hosts (hostname);
@@ -0,0 +1 @@
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS hostname TEXT;
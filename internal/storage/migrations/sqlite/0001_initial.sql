@@ -0,0 +1,16 @@
+-- Esquema original de results.db
+CREATE TABLE IF NOT EXISTS servers (
+	ip TEXT,
+	port INTEGER,
+	version_name TEXT,
+	protocol INTEGER,
+	players_online INTEGER,
+	players_max INTEGER,
+	whitelist BOOLEAN,
+	software TEXT,
+	mods TEXT,
+	plugins TEXT,
+	secure_chat BOOLEAN,
+	timestamp DATETIME,
+	UNIQUE(ip, port)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE servers ADD COLUMN motd TEXT;
+ALTER TABLE servers ADD COLUMN motd_json TEXT;
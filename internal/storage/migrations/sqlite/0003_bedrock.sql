@@ -0,0 +1,7 @@
+ALTER TABLE servers ADD COLUMN edition TEXT;
+ALTER TABLE servers ADD COLUMN server_guid TEXT;
+ALTER TABLE servers ADD COLUMN level_name TEXT;
+ALTER TABLE servers ADD COLUMN game_mode TEXT;
+ALTER TABLE servers ADD COLUMN port_v4 INTEGER;
+ALTER TABLE servers ADD COLUMN port_v6 INTEGER;
+UPDATE servers SET edition = 'java' WHERE edition IS NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE servers ADD COLUMN icon_hash TEXT;
+CREATE TABLE IF NOT EXISTS icons (
+	hash TEXT PRIMARY KEY,
+	data BLOB
+);
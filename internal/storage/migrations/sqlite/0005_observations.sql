@@ -0,0 +1,16 @@
+ALTER TABLE servers ADD COLUMN first_seen DATETIME;
+ALTER TABLE servers ADD COLUMN last_seen DATETIME;
+UPDATE servers SET first_seen = timestamp, last_seen = timestamp WHERE first_seen IS NULL;
+CREATE TABLE IF NOT EXISTS observations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	ip TEXT,
+	port INTEGER,
+	timestamp DATETIME,
+	version_name TEXT,
+	protocol INTEGER,
+	players_online INTEGER,
+	players_max INTEGER,
+	motd_hash TEXT,
+	whitelist BOOLEAN
+);
+CREATE INDEX IF NOT EXISTS idx_observations_server ON observations (ip, port, timestamp);
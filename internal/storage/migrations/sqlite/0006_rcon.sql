@@ -0,0 +1,2 @@
+-- RCON detectado en el puerto (respuesta de autenticación válida)
+ALTER TABLE servers ADD COLUMN rcon_open BOOLEAN;
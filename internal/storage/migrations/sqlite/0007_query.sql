@@ -0,0 +1,3 @@
+-- Claves y jugadores de la respuesta Query (GameSpy4), en JSON
+ALTER TABLE servers ADD COLUMN query_kv TEXT;
+ALTER TABLE servers ADD COLUMN query_players TEXT;
@@ -0,0 +1,12 @@
+-- Jugadores vistos en el sample del Server List Ping o en la lista de Query
+CREATE TABLE IF NOT EXISTS player_sightings (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	uuid TEXT,
+	name TEXT,
+	ip TEXT,
+	port INTEGER,
+	source TEXT,
+	timestamp DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_player_sightings_uuid ON player_sightings (uuid);
+CREATE INDEX IF NOT EXISTS idx_player_sightings_name ON player_sightings (LOWER(name));
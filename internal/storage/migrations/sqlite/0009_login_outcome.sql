@@ -0,0 +1,4 @@
+-- Respuesta al Login Start: online, offline, whitelisted, banned,
+-- modded_required, proxy_rejected o kicked. NULL si no se pudo probar.
+ALTER TABLE servers ADD COLUMN login_outcome TEXT;
+CREATE INDEX IF NOT EXISTS idx_servers_login_outcome ON servers (login_outcome);
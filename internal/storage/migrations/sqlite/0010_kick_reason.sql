@@ -0,0 +1,3 @@
+-- Texto plano del kick en el login y su categoría según las reglas de clasificación
+ALTER TABLE servers ADD COLUMN kick_reason TEXT;
+ALTER TABLE servers ADD COLUMN kick_category TEXT;
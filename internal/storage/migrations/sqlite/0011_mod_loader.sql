@@ -0,0 +1,6 @@
+-- Cargador de mods (fml, forge, neoforge), versión de red de FML, canales
+-- registrados (JSON) y si Forge recortó la lista de mods del status
+ALTER TABLE servers ADD COLUMN mod_loader TEXT;
+ALTER TABLE servers ADD COLUMN fml_network_version INTEGER;
+ALTER TABLE servers ADD COLUMN mod_channels TEXT;
+ALTER TABLE servers ADD COLUMN mods_truncated BOOLEAN;
@@ -0,0 +1,3 @@
+-- Confianza (0-1) de la implementación deducida en software
+ALTER TABLE servers ADD COLUMN software_confidence REAL;
+CREATE INDEX IF NOT EXISTS idx_servers_software ON servers (software);
@@ -0,0 +1,6 @@
+-- Proxies (BungeeCord, Velocity...) y rango de protocolos que aceptan
+ALTER TABLE servers ADD COLUMN proxy_type TEXT;
+ALTER TABLE servers ADD COLUMN protocol_min INTEGER;
+ALTER TABLE servers ADD COLUMN protocol_max INTEGER;
+ALTER TABLE servers ADD COLUMN host_routing BOOLEAN;
+ALTER TABLE servers ADD COLUMN geyser_hybrid BOOLEAN;
@@ -0,0 +1,2 @@
+-- Dominio por el que se llegó al servidor (listas de hostnames y SRV)
+ALTER TABLE servers ADD COLUMN hostname TEXT;
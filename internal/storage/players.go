@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Orígenes de un avistamiento de jugador.
+const (
+	SightingSLP   = "slp"
+	SightingQuery = "query"
+)
+
+// Sighting es un jugador visto en un servidor durante un sondeo. Los jugadores
+// de Query no traen UUID.
+type Sighting struct {
+	UUID      string    `json:"uuid"`
+	Name      string    `json:"name"`
+	IP        string    `json:"ip"`
+	Port      int       `json:"port"`
+	Source    string    `json:"source"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SightingFilter busca por UUID o por nombre (sin distinguir mayúsculas).
+// Con ambos se devuelven los avistamientos que cumplan cualquiera. Los
+// resultados van del más reciente al más antiguo, así que Limit se queda con
+// los últimos.
+type SightingFilter struct {
+	UUID  string
+	Name  string
+	Limit int
+}
+
+var sightingColumns = []string{"uuid", "name", "ip", "port", "source", "timestamp"}
+
+// sightingRows extrae los avistamientos de un resultado en el orden de sightingColumns
+func sightingRows(s *protocol.ServerDetail, ts time.Time) [][]interface{} {
+	rows := make([][]interface{}, 0, len(s.PlayerSample)+len(s.QueryPlayers))
+	for _, p := range s.PlayerSample {
+		rows = append(rows, []interface{}{NormalizeUUID(p.ID), p.Name, s.IP, s.Port, SightingSLP, ts})
+	}
+	for _, name := range s.QueryPlayers {
+		rows = append(rows, []interface{}{"", name, s.IP, s.Port, SightingQuery, ts})
+	}
+	return rows
+}
+
+// NormalizeUUID pasa un UUID a minúsculas y con guiones, que es como lo
+// envía el Server List Ping. Lo que no parece un UUID se devuelve tal cual.
+func NormalizeUUID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	hex := strings.ReplaceAll(id, "-", "")
+	if len(hex) != 32 || strings.Trim(hex, "0123456789abcdef") != "" {
+		return id
+	}
+	return hex[0:8] + "-" + hex[8:12] + "-" + hex[12:16] + "-" + hex[16:20] + "-" + hex[20:32]
+}
+
+// IsUUID indica si s es un UUID con o sin guiones.
+func IsUUID(s string) bool {
+	n := NormalizeUUID(s)
+	return len(n) == 36 && strings.Count(n, "-") == 4
+}
+
+func querySightings(ctx context.Context, db *sql.DB, d dialect, filter SightingFilter, fn func(*Sighting) error) error {
+	var where []string
+	var args []interface{}
+	if filter.UUID != "" {
+		args = append(args, NormalizeUUID(filter.UUID))
+		where = append(where, "uuid = "+d.placeholder(len(args)))
+	}
+	if filter.Name != "" {
+		args = append(args, filter.Name)
+		where = append(where, fmt.Sprintf("LOWER(name) = LOWER(%s)", d.placeholder(len(args))))
+	}
+
+	query := `SELECT COALESCE(uuid, ''), COALESCE(name, ''), ip, port, COALESCE(source, ''), timestamp FROM player_sightings`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " OR ")
+	}
+	query += " ORDER BY timestamp DESC, ip, port"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s Sighting
+		var ts sql.NullTime
+		if err := rows.Scan(&s.UUID, &s.Name, &s.IP, &s.Port, &s.Source, &ts); err != nil {
+			return err
+		}
+		s.Timestamp = ts.Time
+		if err := fn(&s); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// PostgresStore implementa Store sobre PostgreSQL. Los lotes se cargan con
+// COPY en una tabla temporal y se fusionan con ON CONFLICT, de modo que varios
+// escáneres pueden escribir en la misma base sin pisarse.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+	db   *sql.DB
+}
+
+func OpenPostgres(dsn string) (*PostgresStore, error) {
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(context.Background()); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return &PostgresStore{pool: pool, db: stdlib.OpenDBFromPool(pool)}, nil
+}
+
+func (s *PostgresStore) Migrate(ctx context.Context) (int, error) {
+	return migrate(ctx, s.db, postgresDialect)
+}
+
+func (s *PostgresStore) SchemaVersion(ctx context.Context) (int, int, error) {
+	current, err := schemaVersion(ctx, s.db, postgresDialect)
+	if err != nil {
+		return 0, 0, err
+	}
+	latest, err := postgresDialect.latestVersion()
+	return current, latest, err
+}
+
+var observationColumns = []string{
+	"ip", "port", "timestamp", "version_name", "protocol", "players_online", "players_max", "motd_hash", "whitelist",
+}
+
+func (s *PostgresStore) WriteBatch(ctx context.Context, batch []*protocol.ServerDetail) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	_, err = tx.Exec(ctx, `CREATE TEMP TABLE servers_staging (LIKE servers INCLUDING DEFAULTS) ON COMMIT DROP`)
+	if err != nil {
+		return err
+	}
+
+	serverRows := make([][]interface{}, 0, len(batch))
+	obsRows := make([][]interface{}, 0, len(batch))
+	var sightRows [][]interface{}
+	icons := &pgx.Batch{}
+	// Los virtual hosts son pocos por lote: un upsert por fila basta
+	vhosts := &pgx.Batch{}
+	vhostSQL := upsertVirtualHostSQL(postgresDialect)
+
+	for _, srv := range batch {
+		ts := srv.Timestamp
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		ts = ts.UTC()
+
+		var iconHash interface{}
+		if srv.IconHash != "" {
+			icons.Queue(`INSERT INTO icons (hash, data) VALUES ($1, $2) ON CONFLICT (hash) DO NOTHING`, srv.IconHash, srv.Icon)
+			iconHash = srv.IconHash
+		}
+
+		serverRows = append(serverRows, append(serverValues(srv, iconHash, ts), ts, ts))
+		obsRows = append(obsRows, []interface{}{
+			srv.IP, srv.Port, ts, srv.VersionName, srv.Protocol, srv.PlayersOnline, srv.PlayersMax,
+			MOTDHash(srv.MOTD), srv.IsWhitelist,
+		})
+		sightRows = append(sightRows, sightingRows(srv, ts)...)
+
+		for _, v := range srv.VirtualHosts {
+			if v.IconHash != "" {
+				icons.Queue(`INSERT INTO icons (hash, data) VALUES ($1, $2) ON CONFLICT (hash) DO NOTHING`, v.IconHash, v.Icon)
+			}
+		}
+		for _, row := range virtualHostRows(srv, ts) {
+			vhosts.Queue(vhostSQL, row...)
+		}
+	}
+
+	if icons.Len() > 0 {
+		if err := tx.SendBatch(ctx, icons).Close(); err != nil {
+			return err
+		}
+	}
+
+	columns := append(append([]string{}, serverColumns...), "first_seen", "last_seen")
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"servers_staging"}, columns, pgx.CopyFromRows(serverRows)); err != nil {
+		return err
+	}
+
+	// DISTINCT ON evita que un mismo (ip, port) aparezca dos veces en el ON CONFLICT.
+	// Se queda la fila más reciente, pero first_seen es el menor del lote, como en SQLite
+	selected := make([]string, len(columns))
+	for i, col := range columns {
+		selected[i] = col
+		if col == "first_seen" {
+			selected[i] = "MIN(first_seen) OVER (PARTITION BY ip, port)"
+		}
+	}
+	merge := fmt.Sprintf(`
+		INSERT INTO servers (%s)
+		SELECT DISTINCT ON (ip, port) %s FROM servers_staging ORDER BY ip, port, timestamp DESC
+		ON CONFLICT (ip, port) DO UPDATE SET %s`, strings.Join(columns, ", "), strings.Join(selected, ", "), upsertAssignments())
+	if _, err := tx.Exec(ctx, merge); err != nil {
+		return err
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"observations"}, observationColumns, pgx.CopyFromRows(obsRows)); err != nil {
+		return err
+	}
+	if len(sightRows) > 0 {
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"player_sightings"}, sightingColumns, pgx.CopyFromRows(sightRows)); err != nil {
+			return err
+		}
+	}
+	if vhosts.Len() > 0 {
+		if err := tx.SendBatch(ctx, vhosts).Close(); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *PostgresStore) QueryServers(ctx context.Context, filter Filter, fn func(*ServerRecord) error) error {
+	return queryServers(ctx, s.db, postgresDialect, filter, fn)
+}
+
+func (s *PostgresStore) QuerySightings(ctx context.Context, filter SightingFilter, fn func(*Sighting) error) error {
+	return querySightings(ctx, s.db, postgresDialect, filter, fn)
+}
+
+func (s *PostgresStore) QueryVirtualHosts(ctx context.Context, filter VirtualHostFilter, fn func(*VirtualHostRecord) error) error {
+	return queryVirtualHosts(ctx, s.db, postgresDialect, filter, fn)
+}
+
+func (s *PostgresStore) Close() error {
+	err := s.db.Close()
+	s.pool.Close()
+	return err
+}
@@ -2,42 +2,47 @@ package storage
 
 import (
 	"MinecraftCrawler/internal/protocol"
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
 func NewDatabase(path string) (*sql.DB, error) {
+	db, err := OpenDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// El esquema se crea y actualiza mediante migraciones versionadas (ver migrations/sqlite)
+	if _, err := Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenDatabase abre la base de datos sin tocar el esquema
+func OpenDatabase(path string) (*sql.DB, error) {
 	db, err := sql.Open("sqlite", path)
 	if err != nil {
 		return nil, err
 	}
 
-	// Restauramos PRAGMA NORMAL para seguridad de datos y añadimos todos los campos
-	// Se añade UNIQUE(ip, port) para evitar duplicados
+	// Restauramos PRAGMA NORMAL para seguridad de datos
 	query := `
 		PRAGMA journal_mode = WAL;
-		PRAGMA synchronous = NORMAL;
-		CREATE TABLE IF NOT EXISTS servers (
-			ip TEXT,
-			port INTEGER,
-			version_name TEXT,
-			protocol INTEGER,
-			players_online INTEGER,
-			players_max INTEGER,
-			whitelist BOOLEAN,
-			software TEXT,
-			mods TEXT,
-			plugins TEXT,
-			secure_chat BOOLEAN,
-			timestamp DATETIME,
-			UNIQUE(ip, port)
-		);`
+		PRAGMA synchronous = NORMAL;`
 
 	if _, err := db.Exec(query); err != nil {
+		db.Close()
 		return nil, err
 	}
 	return db, nil
@@ -45,20 +50,142 @@ func NewDatabase(path string) (*sql.DB, error) {
 
 // Renombramos a StartSQLiteManager para evitar colisión con buffer.go
 func StartSQLiteManager(db *sql.DB, resultChan <-chan *protocol.ServerDetail, batchSize int) {
-	buffer := make([]*protocol.ServerDetail, 0, batchSize)
+	<-StartManager(context.Background(), &SQLiteStore{db: db}, resultChan, batchSize)
+}
 
-	for res := range resultChan {
-		buffer = append(buffer, res)
-		if len(buffer) >= batchSize {
-			if err := Flush(db, buffer); err != nil {
-				log.Printf("Error flushing batch: %v", err)
-			}
-			buffer = buffer[:0]
+// SQLiteStore implementa Store sobre un fichero SQLite local
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := OpenDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// DB expone la conexión para consultas que no cubre Store
+func (s *SQLiteStore) DB() *sql.DB {
+	return s.db
+}
+
+func (s *SQLiteStore) Migrate(ctx context.Context) (int, error) {
+	return migrate(ctx, s.db, sqliteDialect)
+}
+
+func (s *SQLiteStore) SchemaVersion(ctx context.Context) (int, int, error) {
+	current, err := schemaVersion(ctx, s.db, sqliteDialect)
+	if err != nil {
+		return 0, 0, err
+	}
+	latest, err := sqliteDialect.latestVersion()
+	return current, latest, err
+}
+
+func (s *SQLiteStore) WriteBatch(ctx context.Context, batch []*protocol.ServerDetail) error {
+	return Flush(s.db, batch)
+}
+
+func (s *SQLiteStore) QueryServers(ctx context.Context, filter Filter, fn func(*ServerRecord) error) error {
+	return queryServers(ctx, s.db, sqliteDialect, filter, fn)
+}
+
+func (s *SQLiteStore) QuerySightings(ctx context.Context, filter SightingFilter, fn func(*Sighting) error) error {
+	return querySightings(ctx, s.db, sqliteDialect, filter, fn)
+}
+
+func (s *SQLiteStore) QueryVirtualHosts(ctx context.Context, filter VirtualHostFilter, fn func(*VirtualHostRecord) error) error {
+	return queryVirtualHosts(ctx, s.db, sqliteDialect, filter, fn)
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// serverColumns lista las columnas de servers en el mismo orden que devuelve serverValues
+var serverColumns = []string{
+	"ip", "port", "edition", "version_name", "protocol", "motd", "motd_json", "icon_hash",
+	"players_online", "players_max", "whitelist", "software", "mods", "plugins", "secure_chat",
+	"server_guid", "level_name", "game_mode", "port_v4", "port_v6", "rcon_open", "query_kv", "query_players",
+	"login_outcome", "kick_reason", "kick_category",
+	"mod_loader", "fml_network_version", "mod_channels", "mods_truncated", "software_confidence",
+	"proxy_type", "protocol_min", "protocol_max", "host_routing", "geyser_hybrid", "hostname", "timestamp",
+}
+
+func serverValues(s *protocol.ServerDetail, iconHash interface{}, ts time.Time) []interface{} {
+	modsJSON, _ := json.Marshal(s.Mods)
+	pluginsJSON, _ := json.Marshal(s.Plugins)
+
+	return []interface{}{
+		s.IP, s.Port, s.Edition, s.VersionName, s.Protocol, s.MOTD, s.MOTDJSON, iconHash,
+		s.PlayersOnline, s.PlayersMax, s.IsWhitelist, s.Software, string(modsJSON), string(pluginsJSON), s.EnforcesSecureChat,
+		s.ServerGUID, s.LevelName, s.GameMode, s.PortV4, s.PortV6, s.RconOpen,
+		optionalJSON(len(s.QueryKV), s.QueryKV), optionalJSON(len(s.QueryPlayers), s.QueryPlayers),
+		optionalString(string(s.LoginOutcome)), optionalString(s.KickReason), optionalString(string(s.KickCategory)),
+		optionalString(s.ModLoader), s.FMLNetworkVersion, optionalJSON(len(s.ModChannels), s.ModChannels), s.ModsTruncated, s.SoftwareConfidence,
+		optionalString(s.ProxyType), s.ProtocolMin, s.ProtocolMax, s.HostRouting, s.GeyserHybrid, optionalString(s.Hostname), ts,
+	}
+}
+
+// optionalString guarda NULL para los valores sin determinar
+func optionalString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// optionalJSON guarda NULL en lugar de "null" o "{}" cuando no hay datos
+func optionalJSON(n int, v interface{}) interface{} {
+	if n == 0 {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return string(b)
+}
+
+// upsertServerSQL actualiza el estado más reciente de un servidor conservando first_seen
+func upsertServerSQL() string {
+	placeholders := make([]string, len(serverColumns))
+	for i := range serverColumns {
+		placeholders[i] = "?"
+	}
+	return fmt.Sprintf(`
+		INSERT INTO servers (%s, first_seen, last_seen)
+		VALUES (%s, ?, ?)
+		ON CONFLICT(ip, port) DO UPDATE SET %s`,
+		strings.Join(serverColumns, ", "), strings.Join(placeholders, ", "), upsertAssignments())
+}
+
+// upsertAssignments sobrescribe todo salvo la clave y first_seen. Un sondeo
+// por IP no borra el hostname que se conoció al escanear por dominio.
+func upsertAssignments() string {
+	updates := make([]string, 0, len(serverColumns))
+	for _, col := range serverColumns {
+		switch col {
+		case "ip", "port":
+		case "hostname":
+			updates = append(updates, "hostname = COALESCE(excluded.hostname, servers.hostname)")
+		default:
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", col, col))
 		}
 	}
-	if len(buffer) > 0 {
-		_ = Flush(db, buffer)
+	updates = append(updates, "last_seen = excluded.last_seen")
+	return strings.Join(updates, ", ")
+}
+
+// MOTDHash identifica un MOTD en observations sin repetir el texto en cada fila
+func MOTDHash(motd string) string {
+	if motd == "" {
+		return ""
 	}
+	sum := sha256.Sum256([]byte(motd))
+	return hex.EncodeToString(sum[:])
 }
 
 func Flush(db *sql.DB, batch []*protocol.ServerDetail) error {
@@ -67,36 +194,106 @@ func Flush(db *sql.DB, batch []*protocol.ServerDetail) error {
 		return err
 	}
 
-	// Usamos INSERT OR REPLACE para actualizar datos de servidores ya conocidos
-	stmt, err := tx.Prepare(`
-		INSERT OR REPLACE INTO servers (
-			ip, port, version_name, protocol, players_online, players_max, 
-			whitelist, software, mods, plugins, secure_chat, timestamp
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	// servers guarda el último estado conocido; observations conserva cada sondeo
+	stmt, err := tx.Prepare(upsertServerSQL())
 	if err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 	defer stmt.Close()
 
+	obsStmt, err := tx.Prepare(`
+		INSERT INTO observations (
+			ip, port, timestamp, version_name, protocol, players_online, players_max, motd_hash, whitelist
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer obsStmt.Close()
+
+	iconStmt, err := tx.Prepare(`INSERT OR IGNORE INTO icons (hash, data) VALUES (?, ?)`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer iconStmt.Close()
+
+	sightStmt, err := tx.Prepare(fmt.Sprintf(`INSERT INTO player_sightings (%s) VALUES (?, ?, ?, ?, ?, ?)`,
+		strings.Join(sightingColumns, ", ")))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer sightStmt.Close()
+
+	vhostStmt, err := tx.Prepare(upsertVirtualHostSQL(sqliteDialect))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer vhostStmt.Close()
+
+	failed := 0
+	var firstErr error
 	for _, s := range batch {
-		modsJSON, _ := json.Marshal(s.Mods)
-		pluginsJSON, _ := json.Marshal(s.Plugins)
-		
 		ts := s.Timestamp
 		if ts.IsZero() {
 			ts = time.Now()
 		}
+		ts = ts.UTC()
 
-		_, err := stmt.Exec(
-			s.IP, s.Port, s.VersionName, s.Protocol, s.PlayersOnline, s.PlayersMax,
-			s.IsWhitelist, s.Software, string(modsJSON), string(pluginsJSON), 
-			s.EnforcesSecureChat, ts,
-		)
-		if err != nil {
+		var iconHash interface{}
+		if s.IconHash != "" {
+			if _, err := iconStmt.Exec(s.IconHash, s.Icon); err != nil {
+				log.Printf("Error inserting icon for %s: %v", s.IP, err)
+			} else {
+				iconHash = s.IconHash
+			}
+		}
+
+		args := append(serverValues(s, iconHash, ts), ts, ts)
+		if _, err := stmt.Exec(args...); err != nil {
 			log.Printf("Error inserting server %s: %v", s.IP, err)
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
 			continue
 		}
+
+		_, err := obsStmt.Exec(
+			s.IP, s.Port, ts, s.VersionName, s.Protocol, s.PlayersOnline, s.PlayersMax,
+			MOTDHash(s.MOTD), s.IsWhitelist,
+		)
+		if err != nil {
+			log.Printf("Error inserting observation %s: %v", s.IP, err)
+		}
+
+		for _, row := range sightingRows(s, ts) {
+			if _, err := sightStmt.Exec(row...); err != nil {
+				log.Printf("Error inserting player sighting %s: %v", s.IP, err)
+			}
+		}
+
+		for _, v := range s.VirtualHosts {
+			if v.IconHash != "" {
+				if _, err := iconStmt.Exec(v.IconHash, v.Icon); err != nil {
+					log.Printf("Error inserting icon for %s: %v", v.Hostname, err)
+				}
+			}
+		}
+		for _, row := range virtualHostRows(s, ts) {
+			if _, err := vhostStmt.Exec(row...); err != nil {
+				log.Printf("Error inserting virtual host %s: %v", s.IP, err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return &PartialWriteError{Failed: failed, Err: firstErr}
 	}
-	return tx.Commit()
-}
\ No newline at end of file
+	return nil
+}
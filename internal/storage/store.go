@@ -0,0 +1,349 @@
+package storage
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store es un backend de almacenamiento de resultados. SQLite es el backend
+// por defecto; PostgreSQL permite que varios escáneres escriban a la vez.
+type Store interface {
+	// Migrate aplica las migraciones pendientes y devuelve cuántas se ejecutaron.
+	Migrate(ctx context.Context) (int, error)
+	// SchemaVersion devuelve la versión aplicada y la última disponible.
+	SchemaVersion(ctx context.Context) (current int, latest int, err error)
+	// WriteBatch guarda un lote de resultados en una sola transacción.
+	WriteBatch(ctx context.Context, batch []*protocol.ServerDetail) error
+	// QueryServers recorre los servidores que cumplen el filtro.
+	QueryServers(ctx context.Context, filter Filter, fn func(*ServerRecord) error) error
+	// QuerySightings recorre los avistamientos de jugadores que cumplen el filtro.
+	QuerySightings(ctx context.Context, filter SightingFilter, fn func(*Sighting) error) error
+	// QueryVirtualHosts recorre los virtual hosts que cumplen el filtro.
+	QueryVirtualHosts(ctx context.Context, filter VirtualHostFilter, fn func(*VirtualHostRecord) error) error
+	Close() error
+}
+
+// Filter restringe QueryServers. Los campos vacíos no filtran.
+type Filter struct {
+	IP         string
+	Port       int
+	Version    string
+	MinPlayers int
+	Whitelist  *bool
+	Login      protocol.LoginOutcome
+	Software   string
+	Proxy      *bool
+	SeenSince  time.Time
+	Limit      int
+}
+
+// ServerRecord es el último estado conocido de un servidor.
+type ServerRecord struct {
+	protocol.ServerDetail
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Open elige el backend según el DSN: postgres:// o postgresql:// abren
+// PostgreSQL y cualquier otro valor se trata como ruta de fichero SQLite.
+// El esquema no se toca; hay que llamar a Migrate.
+func Open(dsn string) (Store, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		return OpenPostgres(dsn)
+	}
+	return OpenSQLite(strings.TrimPrefix(dsn, "sqlite://"))
+}
+
+// PartialWriteError indica que el lote se confirmó pero algunas filas fallaron.
+type PartialWriteError struct {
+	Failed int
+	Err    error
+}
+
+func (e *PartialWriteError) Error() string {
+	return fmt.Sprintf("%d inserts failed: %v", e.Failed, e.Err)
+}
+
+func (e *PartialWriteError) Unwrap() error {
+	return e.Err
+}
+
+// StartManager agrupa los resultados en lotes y los escribe en el Store en
+// segundo plano. Ver Manager.Start.
+func StartManager(ctx context.Context, store Store, resultChan <-chan *protocol.ServerDetail, batchSize int) <-chan struct{} {
+	return NewManager(store, batchSize, 0).Start(ctx, resultChan)
+}
+
+// ManagerStats resume la actividad de escritura de un Manager.
+type ManagerStats struct {
+	Batches       int64
+	FailedBatches int64
+	Written       int64
+	FailedInserts int64
+	LastLatency   time.Duration
+	MaxLatency    time.Duration
+	TotalLatency  time.Duration
+}
+
+// AvgLatency es la latencia media por lote.
+func (s ManagerStats) AvgLatency() time.Duration {
+	if s.Batches == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Batches)
+}
+
+// Manager escribe lotes cuando se llena el buffer o cuando pasa flushInterval
+// desde el último volcado, lo que ocurra antes, para que la base refleje el
+// progreso aunque los resultados lleguen despacio.
+type Manager struct {
+	store         Store
+	batchSize     int
+	flushInterval time.Duration
+
+	mu    sync.Mutex
+	stats ManagerStats
+}
+
+// NewManager crea un Manager. Con flushInterval 0 solo se vuelca por tamaño.
+func NewManager(store Store, batchSize int, flushInterval time.Duration) *Manager {
+	return &Manager{store: store, batchSize: batchSize, flushInterval: flushInterval}
+}
+
+func (m *Manager) Stats() ManagerStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// Start consume resultChan en segundo plano. El canal devuelto se cierra
+// cuando resultChan se ha cerrado y el último lote está escrito. Al cancelar
+// ctx se vuelca inmediatamente lo pendiente, pero se siguen aceptando
+// resultados hasta que se cierre resultChan.
+func (m *Manager) Start(ctx context.Context, resultChan <-chan *protocol.ServerDetail) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		// Las escrituras no se cancelan: perder el último lote es justo lo que se quiere evitar
+		writeCtx := context.WithoutCancel(ctx)
+		buffer := make([]*protocol.ServerDetail, 0, m.batchSize)
+		flush := func() {
+			if len(buffer) == 0 {
+				return
+			}
+			m.write(writeCtx, buffer)
+			buffer = buffer[:0]
+		}
+
+		var tick <-chan time.Time
+		if m.flushInterval > 0 {
+			ticker := time.NewTicker(m.flushInterval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		cancelled := ctx.Done()
+		for {
+			select {
+			case res, ok := <-resultChan:
+				if !ok {
+					flush()
+					return
+				}
+				buffer = append(buffer, res)
+				if len(buffer) >= m.batchSize {
+					flush()
+				}
+			case <-tick:
+				flush()
+			case <-cancelled:
+				// Lo que ya estaba en el canal se considera pendiente y entra en este volcado
+				for drained := false; !drained && len(buffer) < m.batchSize; {
+					select {
+					case res, ok := <-resultChan:
+						if !ok {
+							flush()
+							return
+						}
+						buffer = append(buffer, res)
+					default:
+						drained = true
+					}
+				}
+				flush()
+				cancelled = nil
+			}
+		}
+	}()
+
+	return done
+}
+
+func (m *Manager) write(ctx context.Context, batch []*protocol.ServerDetail) {
+	start := time.Now()
+	err := m.store.WriteBatch(ctx, batch)
+	latency := time.Since(start)
+
+	written, failed := int64(len(batch)), int64(0)
+	var partial *PartialWriteError
+	switch {
+	case errors.As(err, &partial):
+		failed = int64(partial.Failed)
+		written -= failed
+	case err != nil:
+		log.Printf("Error flushing batch: %v", err)
+		failed, written = written, 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.Batches++
+	if err != nil && partial == nil {
+		m.stats.FailedBatches++
+	}
+	m.stats.Written += written
+	m.stats.FailedInserts += failed
+	m.stats.LastLatency = latency
+	m.stats.TotalLatency += latency
+	if latency > m.stats.MaxLatency {
+		m.stats.MaxLatency = latency
+	}
+}
+
+// serverSelect lee las columnas en el orden que espera scanServer. Las
+// columnas añadidas por migraciones pueden ser NULL en filas antiguas.
+const serverSelect = `
+	SELECT ip, port, COALESCE(edition, ''), COALESCE(version_name, ''), COALESCE(protocol, 0),
+		COALESCE(motd, ''), COALESCE(motd_json, ''), COALESCE(icon_hash, ''),
+		COALESCE(players_online, 0), COALESCE(players_max, 0), COALESCE(whitelist, false),
+		COALESCE(software, ''), COALESCE(mods, ''), COALESCE(plugins, ''), COALESCE(secure_chat, false),
+		COALESCE(server_guid, ''), COALESCE(level_name, ''), COALESCE(game_mode, ''),
+		COALESCE(port_v4, 0), COALESCE(port_v6, 0), COALESCE(rcon_open, false),
+		COALESCE(query_kv, ''), COALESCE(query_players, ''),
+		COALESCE(login_outcome, ''), COALESCE(kick_reason, ''), COALESCE(kick_category, ''),
+		COALESCE(mod_loader, ''), COALESCE(fml_network_version, 0), COALESCE(mod_channels, ''), COALESCE(mods_truncated, false),
+		COALESCE(software_confidence, 0), COALESCE(proxy_type, ''), COALESCE(protocol_min, 0), COALESCE(protocol_max, 0),
+		COALESCE(host_routing, false), COALESCE(geyser_hybrid, false), COALESCE(hostname, ''), timestamp, first_seen, last_seen
+	FROM servers`
+
+func scanServer(rows *sql.Rows) (*ServerRecord, error) {
+	var r ServerRecord
+	var mods, plugins, queryKV, queryPlayers, loginOutcome, kickCategory, modChannels string
+	var ts, firstSeen, lastSeen sql.NullTime
+
+	err := rows.Scan(
+		&r.IP, &r.Port, &r.Edition, &r.VersionName, &r.Protocol,
+		&r.MOTD, &r.MOTDJSON, &r.IconHash,
+		&r.PlayersOnline, &r.PlayersMax, &r.IsWhitelist,
+		&r.Software, &mods, &plugins, &r.EnforcesSecureChat,
+		&r.ServerGUID, &r.LevelName, &r.GameMode,
+		&r.PortV4, &r.PortV6, &r.RconOpen, &queryKV, &queryPlayers,
+		&loginOutcome, &r.KickReason, &kickCategory,
+		&r.ModLoader, &r.FMLNetworkVersion, &modChannels, &r.ModsTruncated, &r.SoftwareConfidence,
+		&r.ProxyType, &r.ProtocolMin, &r.ProtocolMax, &r.HostRouting, &r.GeyserHybrid, &r.Hostname, &ts, &firstSeen, &lastSeen,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Mods = make(map[string]string)
+	if mods != "" {
+		_ = json.Unmarshal([]byte(mods), &r.Mods)
+	}
+	if plugins != "" {
+		_ = json.Unmarshal([]byte(plugins), &r.Plugins)
+	}
+	if queryKV != "" {
+		_ = json.Unmarshal([]byte(queryKV), &r.QueryKV)
+	}
+	if queryPlayers != "" {
+		_ = json.Unmarshal([]byte(queryPlayers), &r.QueryPlayers)
+	}
+	if modChannels != "" {
+		_ = json.Unmarshal([]byte(modChannels), &r.ModChannels)
+	}
+	r.LoginOutcome = protocol.LoginOutcome(loginOutcome)
+	r.KickCategory = protocol.KickCategory(kickCategory)
+	r.Timestamp = ts.Time
+	r.FirstSeen = firstSeen.Time
+	r.LastSeen = lastSeen.Time
+	return &r, nil
+}
+
+func queryServers(ctx context.Context, db *sql.DB, d dialect, filter Filter, fn func(*ServerRecord) error) error {
+	var where []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, d.placeholder(len(args))))
+	}
+
+	if filter.IP != "" {
+		add("ip = %s", filter.IP)
+	}
+	if filter.Port != 0 {
+		add("port = %s", filter.Port)
+	}
+	if filter.Version != "" {
+		add("version_name LIKE %s", "%"+filter.Version+"%")
+	}
+	if filter.MinPlayers > 0 {
+		add("players_online >= %s", filter.MinPlayers)
+	}
+	if filter.Whitelist != nil {
+		add("whitelist = %s", *filter.Whitelist)
+	}
+	if filter.Software != "" {
+		add("LOWER(software) = LOWER(%s)", filter.Software)
+	}
+	if filter.Proxy != nil {
+		if *filter.Proxy {
+			where = append(where, "COALESCE(proxy_type, '') <> ''")
+		} else {
+			where = append(where, "COALESCE(proxy_type, '') = ''")
+		}
+	}
+	if filter.Login != protocol.LoginUnknown {
+		add("login_outcome = %s", string(filter.Login))
+	}
+	if !filter.SeenSince.IsZero() {
+		// Las fechas se guardan en UTC, así que en SQLite la comparación de texto respeta el orden
+		add("last_seen >= %s", filter.SeenSince.UTC())
+	}
+
+	query := serverSelect
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY ip, port"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rec, err := scanServer(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// VirtualHostRecord es un hostname que obtiene de ip:port una respuesta
+// distinta de la de la IP a secas.
+type VirtualHostRecord struct {
+	IP            string    `json:"ip"`
+	Port          int       `json:"port"`
+	Hostname      string    `json:"hostname"`
+	Source        string    `json:"source"`
+	VersionName   string    `json:"version_name"`
+	Protocol      int       `json:"protocol"`
+	MOTD          string    `json:"motd"`
+	IconHash      string    `json:"icon_hash"`
+	PlayersOnline int       `json:"players_online"`
+	PlayersMax    int       `json:"players_max"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// VirtualHostFilter busca por IP o por hostname (sin distinguir mayúsculas).
+type VirtualHostFilter struct {
+	IP       string
+	Hostname string
+	Limit    int
+}
+
+var virtualHostColumns = []string{
+	"ip", "port", "hostname", "source", "version_name", "protocol", "motd", "motd_json", "icon_hash",
+	"players_online", "players_max", "first_seen", "last_seen",
+}
+
+// upsertVirtualHostSQL guarda el último estado de cada (ip, port, hostname)
+// conservando first_seen, igual que servers.
+func upsertVirtualHostSQL(d dialect) string {
+	placeholders := make([]string, len(virtualHostColumns))
+	updates := make([]string, 0, len(virtualHostColumns))
+	for i, col := range virtualHostColumns {
+		placeholders[i] = d.placeholder(i + 1)
+		switch col {
+		case "ip", "port", "hostname", "first_seen":
+		default:
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", col, col))
+		}
+	}
+	return fmt.Sprintf(`
+		INSERT INTO virtual_hosts (%s)
+		VALUES (%s)
+		ON CONFLICT (ip, port, hostname) DO UPDATE SET %s`,
+		strings.Join(virtualHostColumns, ", "), strings.Join(placeholders, ", "), strings.Join(updates, ", "))
+}
+
+// virtualHostRows extrae los virtual hosts de un resultado en el orden de virtualHostColumns
+func virtualHostRows(s *protocol.ServerDetail, ts time.Time) [][]interface{} {
+	rows := make([][]interface{}, 0, len(s.VirtualHosts))
+	for _, v := range s.VirtualHosts {
+		rows = append(rows, []interface{}{
+			s.IP, s.Port, strings.ToLower(v.Hostname), v.Source, v.VersionName, v.Protocol, v.MOTD, v.MOTDJSON,
+			optionalString(v.IconHash), v.PlayersOnline, v.PlayersMax, ts, ts,
+		})
+	}
+	return rows
+}
+
+func queryVirtualHosts(ctx context.Context, db *sql.DB, d dialect, filter VirtualHostFilter, fn func(*VirtualHostRecord) error) error {
+	var where []string
+	var args []interface{}
+	if filter.IP != "" {
+		args = append(args, filter.IP)
+		where = append(where, "ip = "+d.placeholder(len(args)))
+	}
+	if filter.Hostname != "" {
+		args = append(args, strings.ToLower(filter.Hostname))
+		where = append(where, "hostname = "+d.placeholder(len(args)))
+	}
+
+	query := `SELECT ip, port, hostname, COALESCE(source, ''), COALESCE(version_name, ''), COALESCE(protocol, 0),
+		COALESCE(motd, ''), COALESCE(icon_hash, ''), COALESCE(players_online, 0), COALESCE(players_max, 0),
+		first_seen, last_seen FROM virtual_hosts`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY ip, port, hostname"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v VirtualHostRecord
+		var firstSeen, lastSeen sql.NullTime
+		if err := rows.Scan(&v.IP, &v.Port, &v.Hostname, &v.Source, &v.VersionName, &v.Protocol,
+			&v.MOTD, &v.IconHash, &v.PlayersOnline, &v.PlayersMax, &firstSeen, &lastSeen); err != nil {
+			return err
+		}
+		v.FirstSeen, v.LastSeen = firstSeen.Time, lastSeen.Time
+		if err := fn(&v); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,282 @@
+package vhost
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"MinecraftCrawler/internal/scanner"
+	"bufio"
+	"context"
+	"crypto/tls"
+	"io"
+	"log"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCandidates limita los hostnames que se prueban por endpoint: un
+// certificado de hosting compartido puede listar cientos de dominios.
+const maxCandidates = 16
+
+// resolveWorkers son las consultas DNS simultáneas de LoadHostList.
+const resolveWorkers = 32
+
+// Candidate es un hostname pendiente de probar y de dónde salió.
+type Candidate struct {
+	Hostname string
+	Source   string
+}
+
+// Finder reúne hostnames para la IP de cada servidor encontrado y repite el
+// ping de estado con ellos. Solo se conservan los que cambian la respuesta.
+type Finder struct {
+	// ReverseDNS añade los nombres de los registros PTR de la IP.
+	ReverseDNS bool
+	// CertPorts son puertos TLS (443, 8443...) cuyo certificado aporta nombres.
+	CertPorts []int
+	// SRV busca _minecraft._tcp en cada nombre y añade el destino del registro
+	// si apunta a la misma IP y puerto, que es lo que envía el cliente.
+	SRV bool
+	// Hosts son los hostnames de la lista del usuario por IP (LoadHostList).
+	Hosts   map[string][]string
+	Timeout time.Duration
+	// Resolver resuelve PTR, SRV y A/AAAA; nil usa scanner.DefaultResolver.
+	Resolver scanner.Resolver
+}
+
+// Probe devuelve los virtual hosts de un servidor Java ya analizado.
+func (f *Finder) Probe(ctx context.Context, detail *protocol.ServerDetail) []protocol.VirtualHost {
+	var hosts []protocol.VirtualHost
+	for _, c := range f.Candidates(ctx, detail.IP, detail.Port) {
+		if ctx.Err() != nil {
+			break
+		}
+		vh, err := protocol.ProbeVirtualHost(detail.IP, detail.Port, c.Hostname, f.timeout())
+		if err != nil || vh.SameStatus(detail) {
+			continue
+		}
+		vh.Source = c.Source
+		hosts = append(hosts, *vh)
+	}
+	return hosts
+}
+
+// Candidates lista los hostnames de todas las fuentes activas, sin repetir
+// y en orden de fiabilidad: lista del usuario, SRV, certificado y PTR.
+func (f *Finder) Candidates(ctx context.Context, ip string, port int) []Candidate {
+	ip = canonicalIP(ip)
+
+	// Los nombres se recortan a maxCandidates antes de consultar su SRV: cada
+	// uno cuesta dos búsquedas DNS
+	var names []Candidate
+	seen := make(map[string]bool)
+	collect := func(name, source string) {
+		name = normalizeHostname(name)
+		if name == "" || seen[name] || len(names) >= maxCandidates {
+			return
+		}
+		seen[name] = true
+		names = append(names, Candidate{Hostname: name, Source: source})
+	}
+	for _, name := range f.Hosts[ip] {
+		collect(name, protocol.HostSourceList)
+	}
+	for _, p := range f.CertPorts {
+		if ctx.Err() != nil || len(names) >= maxCandidates {
+			break
+		}
+		for _, name := range certificateNames(ctx, ip, p, f.timeout()) {
+			collect(name, protocol.HostSourceCertificate)
+		}
+	}
+	if f.ReverseDNS && ctx.Err() == nil && len(names) < maxCandidates {
+		if ptr, err := f.resolver().LookupAddr(ctx, ip); err == nil {
+			for _, name := range ptr {
+				collect(name, protocol.HostSourceReverseDNS)
+			}
+		}
+	}
+
+	var srv []Candidate
+	if f.SRV {
+		for _, c := range names {
+			if ctx.Err() != nil {
+				break
+			}
+			if target := f.srvTarget(ctx, c.Hostname, ip, port); target != "" {
+				srv = append(srv, Candidate{Hostname: target, Source: protocol.HostSourceSRV})
+			}
+		}
+	}
+
+	var found []Candidate
+	added := make(map[string]bool)
+	add := func(c Candidate) {
+		c.Hostname = normalizeHostname(c.Hostname)
+		if c.Hostname == "" || added[c.Hostname] || len(found) >= maxCandidates {
+			return
+		}
+		added[c.Hostname] = true
+		found = append(found, c)
+	}
+	for _, c := range names {
+		if c.Source == protocol.HostSourceList {
+			add(c)
+		}
+	}
+	for _, c := range srv {
+		add(c)
+	}
+	for _, c := range names {
+		add(c)
+	}
+	return found
+}
+
+// srvTarget devuelve el destino de _minecraft._tcp.name si lleva a ip:port.
+func (f *Finder) srvTarget(ctx context.Context, name, ip string, port int) string {
+	_, records, err := f.resolver().LookupSRV(ctx, "minecraft", "tcp", name)
+	if err != nil {
+		return ""
+	}
+	for _, r := range records {
+		if int(r.Port) != port {
+			continue
+		}
+		addrs, err := f.resolver().LookupHost(ctx, strings.TrimSuffix(r.Target, "."))
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if canonicalIP(addr) == ip {
+				return r.Target
+			}
+		}
+	}
+	return ""
+}
+
+// certificateNames lee los nombres del certificado que sirve ip:port. No se
+// valida la cadena: solo interesan los dominios que declara.
+func certificateNames(ctx context.Context, ip string, port int, timeout time.Duration) []string {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout},
+		Config:    &tls.Config{InsecureSkipVerify: true},
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(port)))
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil
+	}
+	return append([]string{certs[0].Subject.CommonName}, certs[0].DNSNames...)
+}
+
+// LoadHostList lee una lista de hostnames (uno por línea, '#' inicia un
+// comentario) y los agrupa por las IPs a las que resuelven, de modo que cada
+// nombre solo se prueba contra sus propios servidores. Las consultas van en
+// paralelo, pero cada IP conserva el orden de la lista.
+func LoadHostList(ctx context.Context, r io.Reader, resolver scanner.Resolver) (map[string][]string, error) {
+	if resolver == nil {
+		resolver = scanner.DefaultResolver
+	}
+	type entry struct {
+		line  int
+		name  string
+		addrs []string
+		err   error
+	}
+	var entries []*entry
+	sc := bufio.NewScanner(r)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		if name := normalizeHostname(line); name != "" {
+			entries = append(entries, &entry{line: lineNo, name: name})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	jobs := make(chan *entry)
+	var wg sync.WaitGroup
+	for i := 0; i < min(resolveWorkers, len(entries)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range jobs {
+				e.addrs, e.err = resolver.LookupHost(ctx, e.name)
+			}
+		}()
+	}
+	for _, e := range entries {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- e
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	hosts := make(map[string][]string)
+	for _, e := range entries {
+		if e.err != nil {
+			log.Printf("[!] Línea %d: no se pudo resolver %s: %v", e.line, e.name, e.err)
+			continue
+		}
+		for _, addr := range e.addrs {
+			ip := canonicalIP(addr)
+			hosts[ip] = append(hosts[ip], e.name)
+		}
+	}
+	return hosts, nil
+}
+
+// normalizeHostname deja el nombre en minúsculas y sin punto final. Los
+// comodines se reducen al dominio padre y lo que no es un nombre con al
+// menos un punto (IPs, "localhost") se descarta.
+func normalizeHostname(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	name = strings.TrimPrefix(name, "*.")
+	if !strings.Contains(name, ".") || strings.ContainsAny(name, " */:") {
+		return ""
+	}
+	if _, err := netip.ParseAddr(name); err == nil {
+		return ""
+	}
+	return name
+}
+
+func canonicalIP(ip string) string {
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		return addr.Unmap().String()
+	}
+	return ip
+}
+
+func (f *Finder) timeout() time.Duration {
+	if f.Timeout <= 0 {
+		return 4 * time.Second
+	}
+	return f.Timeout
+}
+
+func (f *Finder) resolver() scanner.Resolver {
+	if f.Resolver != nil {
+		return f.Resolver
+	}
+	return scanner.DefaultResolver
+}
@@ -0,0 +1,22 @@
+package cmd_test
+
+import (
+	"MinecraftCrawler/cmd"
+	"testing"
+)
+
+func TestDBCommandStructure(t *testing.T) {
+	if cmd.DBCmd.Use != "db" {
+		t.Errorf("Command use = %s; want db", cmd.DBCmd.Use)
+	}
+
+	subcommands := map[string]bool{}
+	for _, c := range cmd.DBCmd.Commands() {
+		subcommands[c.Name()] = true
+	}
+	for _, name := range []string{"migrate", "version"} {
+		if !subcommands[name] {
+			t.Errorf("db subcommand %s not registered", name)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package cmd_test
+
+import (
+	"MinecraftCrawler/cmd"
+	"MinecraftCrawler/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportFlags(t *testing.T) {
+	tests := []struct {
+		flag     string
+		expected string
+	}{
+		{"format", "json"},
+		{"file", "-"},
+		{"version", ""},
+		{"min-players", "0"},
+		{"whitelist", "false"},
+		{"since", "0s"},
+		{"login", ""},
+		{"software", ""},
+		{"proxy", "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			flag := cmd.ExportCmd.Flags().Lookup(tt.flag)
+			if flag == nil {
+				t.Fatalf("Flag %s not found", tt.flag)
+			}
+			if flag.DefValue != tt.expected {
+				t.Errorf("Flag %s default value = %s; want %s", tt.flag, flag.DefValue, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExportRequiresMigratedSchema(t *testing.T) {
+	dir := t.TempDir()
+	dbFile := filepath.Join(dir, "old.db")
+	db, err := storage.OpenDatabase(dbFile)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	root := cmd.ExportCmd.Root()
+	if err := root.PersistentFlags().Set("output", dbFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.ExportCmd.Flags().Set("file", filepath.Join(dir, "out.json")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = root.PersistentFlags().Set("output", "results.db")
+		_ = cmd.ExportCmd.Flags().Set("file", "-")
+	})
+	cmd.ExportCmd.SetContext(context.Background())
+
+	err = cmd.ExportCmd.RunE(cmd.ExportCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "db migrate") {
+		t.Fatalf("export on an unmigrated database = %v, want a hint to run db migrate", err)
+	}
+	if version, err := storage.SchemaVersion(db); err != nil || version != 0 {
+		t.Errorf("export changed the schema: version %d, %v", version, err)
+	}
+
+	if _, err := storage.Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if err := cmd.ExportCmd.RunE(cmd.ExportCmd, nil); err != nil {
+		t.Fatalf("export after migrating failed: %v", err)
+	}
+
+	if err := root.PersistentFlags().Set("output", filepath.Join(dir, "missing.db")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.ExportCmd.RunE(cmd.ExportCmd, nil); err == nil {
+		t.Error("export on a missing database succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.db")); err == nil {
+		t.Error("export created the missing database file")
+	}
+}
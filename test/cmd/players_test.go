@@ -0,0 +1,19 @@
+package cmd_test
+
+import (
+	"MinecraftCrawler/cmd"
+	"testing"
+)
+
+func TestPlayersCommand(t *testing.T) {
+	if cmd.PlayersCmd.Use != "players <uuid|nombre>" {
+		t.Errorf("Command use = %s", cmd.PlayersCmd.Use)
+	}
+	if err := cmd.PlayersCmd.Args(cmd.PlayersCmd, nil); err == nil {
+		t.Error("players without arguments should fail")
+	}
+	flag := cmd.PlayersCmd.Flags().Lookup("limit")
+	if flag == nil || flag.DefValue != "0" {
+		t.Errorf("limit flag = %+v", flag)
+	}
+}
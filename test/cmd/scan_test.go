@@ -15,6 +15,15 @@ func TestScanFlags(t *testing.T) {
 		{"Port", "port", "25565"},
 		{"Workers", "workers", "1000"},
 		{"Verbose", "verbose", "false"},
+		{"FlushInterval", "flush-interval", "10s"},
+		{"Discovery", "discovery", "masscan"},
+		{"Targets", "targets", "-"},
+		{"TargetsFormat", "targets-format", "auto"},
+		{"ConnectConcurrency", "connect-concurrency", "500"},
+		{"KickRules", "kick-rules", ""},
+		{"Vhosts", "vhosts", "[]"},
+		{"VhostList", "vhost-list", ""},
+		{"DNS", "dns", ""},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,19 @@
+package cmd_test
+
+import (
+	"MinecraftCrawler/cmd"
+	"testing"
+)
+
+func TestVhostsCommand(t *testing.T) {
+	if cmd.VhostsCmd.Use != "vhosts [ip|hostname]" {
+		t.Errorf("Command use = %s", cmd.VhostsCmd.Use)
+	}
+	if err := cmd.VhostsCmd.Args(cmd.VhostsCmd, []string{"a", "b"}); err == nil {
+		t.Error("vhosts with two arguments should fail")
+	}
+	flag := cmd.VhostsCmd.Flags().Lookup("limit")
+	if flag == nil || flag.DefValue != "0" {
+		t.Errorf("limit flag = %+v", flag)
+	}
+}
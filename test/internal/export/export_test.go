@@ -0,0 +1,127 @@
+package export_test
+
+import (
+	"MinecraftCrawler/internal/export"
+	"MinecraftCrawler/internal/protocol"
+	"MinecraftCrawler/internal/storage"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleRecords() []*storage.ServerRecord {
+	seen := time.Date(2024, 6, 1, 8, 30, 0, 0, time.UTC)
+	return []*storage.ServerRecord{
+		{
+			ServerDetail: protocol.ServerDetail{
+				IP: "10.0.0.1", Port: 25565, Edition: protocol.EditionJava, VersionName: "1.20.1",
+				MOTD: "Hello, \"world\"", PlayersOnline: 2, PlayersMax: 20,
+				Mods:    map[string]string{"jei": "15.2", "forge": "47.1"},
+				Plugins: []string{"LuckPerms", "EssentialsX"},
+			},
+			FirstSeen: seen, LastSeen: seen,
+		},
+		{
+			ServerDetail: protocol.ServerDetail{IP: "10.0.0.2", Port: 19132, Edition: protocol.EditionBedrock, Mods: map[string]string{}},
+			FirstSeen:    seen, LastSeen: seen,
+		},
+	}
+}
+
+func writeAll(t *testing.T, format string, recs []*storage.ServerRecord) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := export.NewWriter(format, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter(%s) error = %v", format, err)
+	}
+	for _, r := range recs {
+		if err := w.Write(r); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.String()
+}
+
+func TestJSONWriter(t *testing.T) {
+	out := writeAll(t, export.FormatJSON, sampleRecords())
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, out)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("expected 2 elements, got %d", len(decoded))
+	}
+	mods, ok := decoded[0]["mods"].(map[string]interface{})
+	if !ok || mods["jei"] != "15.2" {
+		t.Errorf("mods not exported as an object: %v", decoded[0]["mods"])
+	}
+	if decoded[0]["first_seen"] == nil {
+		t.Error("first_seen missing from JSON output")
+	}
+
+	if empty := writeAll(t, export.FormatJSON, nil); strings.TrimSpace(empty) != "[]" {
+		t.Errorf("empty export = %q, want []", empty)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	out := writeAll(t, export.FormatNDJSON, sampleRecords())
+
+	lines := 0
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		var rec storage.ServerRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("line %d is not JSON: %v", lines, err)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("expected 2 lines, got %d", lines)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	out := writeAll(t, export.FormatCSV, sampleRecords())
+
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected header + 2 rows, got %d", len(rows))
+	}
+
+	col := map[string]int{}
+	for i, name := range rows[0] {
+		col[name] = i
+	}
+	first := rows[1]
+	if first[col["motd"]] != "Hello, \"world\"" {
+		t.Errorf("motd = %q", first[col["motd"]])
+	}
+	if first[col["mods"]] != "forge=47.1;jei=15.2" {
+		t.Errorf("mods = %q, want sorted pairs", first[col["mods"]])
+	}
+	if first[col["plugins"]] != "LuckPerms;EssentialsX" {
+		t.Errorf("plugins = %q", first[col["plugins"]])
+	}
+	if first[col["last_seen"]] != "2024-06-01T08:30:00Z" {
+		t.Errorf("last_seen = %q", first[col["last_seen"]])
+	}
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	if _, err := export.NewWriter("xml", &bytes.Buffer{}); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,155 @@
+package pipeline_test
+
+import (
+	"MinecraftCrawler/internal/mctest"
+	"MinecraftCrawler/internal/pipeline"
+	"MinecraftCrawler/internal/protocol"
+	"MinecraftCrawler/internal/scanner"
+	"MinecraftCrawler/internal/storage"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// El pipeline completo (lista de objetivos -> análisis falso -> SQLite) sin masscan ni red.
+func TestPipelineWritesResults(t *testing.T) {
+	store, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "pipeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if _, err := store.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	targets := &scanner.TargetList{
+		Reader: strings.NewReader("10.0.0.1\n10.0.0.2:25570\n10.0.0.3\n"),
+		Ports:  []int{25565},
+	}
+	analyze := func(ep scanner.Endpoint) (*protocol.ServerDetail, error) {
+		if ep.IP == "10.0.0.3" {
+			return nil, errors.New("timeout")
+		}
+		return &protocol.ServerDetail{IP: ep.IP, Port: ep.Port, Edition: protocol.EditionJava, VersionName: "1.21"}, nil
+	}
+
+	results := make(chan *protocol.ServerDetail, 10)
+	manager := storage.NewManager(store, 100, 0)
+	done := manager.Start(context.Background(), results)
+
+	var found atomic.Int32
+	err = pipeline.Run(context.Background(), pipeline.Config{
+		Discoverer: targets,
+		Analyze:    analyze,
+		Workers:    3,
+		OnResult:   func(*protocol.ServerDetail) { found.Add(1) },
+	}, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(results)
+	<-done
+
+	if found.Load() != 2 {
+		t.Errorf("OnResult called %d times; want 2", found.Load())
+	}
+
+	var got []string
+	err = store.QueryServers(context.Background(), storage.Filter{}, func(rec *storage.ServerRecord) error {
+		got = append(got, scanner.Endpoint{IP: rec.IP, Port: rec.Port}.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "10.0.0.1:25565,10.0.0.2:25570" {
+		t.Errorf("stored servers = %v", got)
+	}
+}
+
+type failingDiscoverer struct{}
+
+func (failingDiscoverer) Discover(ctx context.Context, out chan<- scanner.Endpoint) error {
+	close(out)
+	return errors.New("masscan not found")
+}
+
+func TestPipelineReturnsDiscoveryError(t *testing.T) {
+	err := pipeline.Run(context.Background(), pipeline.Config{
+		Discoverer: failingDiscoverer{},
+		Analyze: func(scanner.Endpoint) (*protocol.ServerDetail, error) {
+			t.Error("analyze called")
+			return nil, nil
+		},
+	}, make(chan *protocol.ServerDetail))
+	if err == nil {
+		t.Error("expected discovery error")
+	}
+}
+
+// Escaneo completo contra servidores falsos: lista de objetivos -> detección de protocolo -> SQLite.
+func TestPipelineEndToEnd(t *testing.T) {
+	var status protocol.StatusResponse
+	status.Version.Name = "1.20.1"
+	status.Version.Protocol = 763
+	status.Players.Max = 20
+	status.Description = "Survival"
+
+	srv, err := mctest.Start(mctest.Config{
+		Status:          status,
+		LoginDisconnect: `{"translate":"multiplayer.disconnect.not_whitelisted"}`,
+		Rcon:            &mctest.Rcon{Password: "secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	store, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "e2e.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if _, err := store.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	targets := &scanner.TargetList{
+		Reader: strings.NewReader(srv.Host + "\n"),
+		Ports:  []int{srv.Port, srv.RconPort},
+	}
+	results := make(chan *protocol.ServerDetail, 10)
+	done := storage.NewManager(store, 100, 0).Start(context.Background(), results)
+
+	err = pipeline.Run(context.Background(), pipeline.Config{
+		Discoverer: targets,
+		Analyze: func(ep scanner.Endpoint) (*protocol.ServerDetail, error) {
+			return protocol.DefaultRegistry.Analyze(protocol.EditionJava, ep.IP, ep.Port, time.Second)
+		},
+		Workers: 2,
+	}, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(results)
+	<-done
+
+	got := make(map[int]*storage.ServerRecord)
+	_ = store.QueryServers(context.Background(), storage.Filter{}, func(rec *storage.ServerRecord) error {
+		got[rec.Port] = rec
+		return nil
+	})
+	if len(got) != 2 {
+		t.Fatalf("stored %d servers, want 2", len(got))
+	}
+	if game := got[srv.Port]; game.VersionName != "1.20.1" || game.MOTD != "Survival" || !game.IsWhitelist || game.RconOpen {
+		t.Errorf("game port = %+v", game)
+	}
+	if rcon := got[srv.RconPort]; !rcon.RconOpen {
+		t.Errorf("rcon port = %+v", rcon)
+	}
+}
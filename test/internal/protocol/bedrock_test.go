@@ -0,0 +1,100 @@
+package protocol_test
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+var raknetMagic = []byte{0x00, 0xFF, 0xFF, 0x00, 0xFE, 0xFE, 0xFE, 0xFE, 0xFD, 0xFD, 0xFD, 0xFD, 0x12, 0x34, 0x56, 0x78}
+
+func buildPong(serverID string) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(0x1C)
+	_ = binary.Write(buf, binary.BigEndian, int64(1234))
+	_ = binary.Write(buf, binary.BigEndian, int64(987654321))
+	buf.Write(raknetMagic)
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(serverID)))
+	buf.WriteString(serverID)
+	return buf.Bytes()
+}
+
+func TestParseBedrockPong(t *testing.T) {
+	pong := buildPong("MCPE;§bDedicated Server;671;1.20.80;3;10;13253860892328930865;Bedrock level;Survival;1;19132;19133;")
+
+	status, err := protocol.ParseBedrockPong(pong)
+	if err != nil {
+		t.Fatalf("ParseBedrockPong() error = %v", err)
+	}
+
+	want := protocol.BedrockStatus{
+		Edition:       "MCPE",
+		MOTD:          "§bDedicated Server",
+		Protocol:      671,
+		VersionName:   "1.20.80",
+		PlayersOnline: 3,
+		PlayersMax:    10,
+		ServerGUID:    "13253860892328930865",
+		LevelName:     "Bedrock level",
+		GameMode:      "Survival",
+		PortV4:        19132,
+		PortV6:        19133,
+	}
+	if *status != want {
+		t.Errorf("ParseBedrockPong() = %+v, want %+v", *status, want)
+	}
+}
+
+func TestParseBedrockPongShortString(t *testing.T) {
+	// Older servers stop after the player counts.
+	status, err := protocol.ParseBedrockPong(buildPong("MCPE;Old;113;1.1.0;0;20"))
+	if err != nil {
+		t.Fatalf("ParseBedrockPong() error = %v", err)
+	}
+	if status.ServerGUID != "987654321" {
+		t.Errorf("ServerGUID = %s, want RakNet GUID 987654321", status.ServerGUID)
+	}
+}
+
+func TestParseBedrockPongInvalid(t *testing.T) {
+	if _, err := protocol.ParseBedrockPong([]byte{0x1C, 0x00}); err == nil {
+		t.Error("expected error for truncated packet")
+	}
+	bad := buildPong("MCPE;x;1;1;0;1")
+	bad[18] = 0x00
+	if _, err := protocol.ParseBedrockPong(bad); err == nil {
+		t.Error("expected error for invalid magic")
+	}
+}
+
+func TestAnalyzeBedrock(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen on udp: %v", err)
+	}
+	defer pc.Close()
+
+	go func() {
+		buf := make([]byte, 1500)
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil || n < 33 || buf[0] != 0x01 {
+			return
+		}
+		_, _ = pc.WriteTo(buildPong("MCPE;Bedrock Test;671;1.20.80;1;5;42;World;Creative;1;19132;19133;"), addr)
+	}()
+
+	port := pc.LocalAddr().(*net.UDPAddr).Port
+	detail, err := protocol.AnalyzeBedrock("127.0.0.1", port, time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeBedrock() error = %v", err)
+	}
+	if detail.Edition != protocol.EditionBedrock {
+		t.Errorf("Edition = %s, want %s", detail.Edition, protocol.EditionBedrock)
+	}
+	if detail.MOTD != "Bedrock Test" || detail.VersionName != "1.20.80" || detail.GameMode != "Creative" {
+		t.Errorf("unexpected detail: %+v", detail)
+	}
+}
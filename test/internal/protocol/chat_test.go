@@ -0,0 +1,58 @@
+package protocol_test
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseChatPlainText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"PlainString", `"A Minecraft Server"`, "A Minecraft Server"},
+		{"LegacyCodes", `"§aGreen §l§nBold§r Normal"`, "Green Bold Normal"},
+		{"TextObject", `{"text":"Hello"}`, "Hello"},
+		{"ExtraTree", `{"text":"","extra":[{"text":"Survival ","color":"gold"},{"text":"1.20","extra":[" | Join!"]}]}`, "Survival 1.20 | Join!"},
+		{"ArrayForm", `["Hello ", {"text":"World","bold":true}]`, "Hello World"},
+		{"Translate", `{"translate":"multiplayer.disconnect.not_whitelisted"}`, "You are not white-listed on this server!"},
+		{"TranslateWith", `{"translate":"chat.type.text","with":["Steve",{"text":"hi"}]}`, "<Steve> hi"},
+		{"TranslatePositional", `{"translate":"%2$s-%1$s","with":["a","b"]}`, "b-a"},
+		{"UnknownKey", `{"translate":"some.custom.key"}`, "some.custom.key"},
+		{"HexLegacy", `"§x§f§f§0§0§0§0Red"`, "Red"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw interface{}
+			if err := json.Unmarshal([]byte(tt.input), &raw); err != nil {
+				t.Fatalf("invalid test input: %v", err)
+			}
+			got := protocol.ParseChat(raw).PlainText()
+			if got != tt.want {
+				t.Errorf("PlainText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseChatNormalizesLegacyColors(t *testing.T) {
+	c := protocol.ParseChat("§cRed§x§1§2§3§4§5§6Hex")
+	if len(c.Extra) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(c.Extra))
+	}
+	if c.Extra[0].Color != "red" || c.Extra[0].Text != "Red" {
+		t.Errorf("first child = %+v, want red 'Red'", c.Extra[0])
+	}
+	if c.Extra[1].Color != "#123456" || c.Extra[1].Text != "Hex" {
+		t.Errorf("second child = %+v, want #123456 'Hex'", c.Extra[1])
+	}
+
+	out := c.JSON()
+	if strings.Contains(out, "§") {
+		t.Errorf("normalized JSON still contains legacy codes: %s", out)
+	}
+}
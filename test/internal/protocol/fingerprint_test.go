@@ -0,0 +1,53 @@
+package protocol_test
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"testing"
+)
+
+func TestFingerprintSoftware(t *testing.T) {
+	velocityLogin := &protocol.LoginProbe{Channels: []string{"velocity:player_info"}}
+	forgeLogin := &protocol.LoginProbe{Channels: []string{"fml:loginwrapper"}}
+	chatReports := &protocol.StatusResponse{PreventsChatReports: true}
+
+	tests := []struct {
+		name    string
+		detail  protocol.ServerDetail
+		status  *protocol.StatusResponse
+		login   *protocol.LoginProbe
+		want    string
+		minConf float64
+	}{
+		{"Paper", protocol.ServerDetail{VersionName: "Paper 1.20.4"}, nil, nil, "Paper", 0.9},
+		{"Purpur", protocol.ServerDetail{VersionName: "Purpur 1.20.4"}, nil, nil, "Purpur", 0.9},
+		{"Folia", protocol.ServerDetail{VersionName: "Folia 1.20.4"}, nil, nil, "Folia", 0.9},
+		{"Spigot", protocol.ServerDetail{VersionName: "Spigot 1.8.8"}, nil, nil, "Spigot", 0.9},
+		{"Velocity", protocol.ServerDetail{VersionName: "Velocity 3.3.0-SNAPSHOT"}, nil, nil, "Velocity", 0.9},
+		{"Waterfall", protocol.ServerDetail{VersionName: "Waterfall 1.8.x-1.20.x"}, nil, nil, "Waterfall", 0.9},
+		{"BungeeCord", protocol.ServerDetail{VersionName: "BungeeCord 1.8.x-1.20.x"}, nil, nil, "BungeeCord", 0.9},
+		{"NanoLimbo", protocol.ServerDetail{VersionName: "NanoLimbo"}, nil, nil, "Limbo", 0.9},
+		{"Quilt", protocol.ServerDetail{VersionName: "Quilt 1.20.1"}, nil, nil, "Quilt", 0.9},
+		{"SpongeQuery", protocol.ServerDetail{VersionName: "1.16.5", QueryKV: map[string]string{"server_mod": "SpongeVanilla"}}, nil, nil, "Sponge", 0.9},
+		{"VanillaPlain", protocol.ServerDetail{VersionName: "1.20.4"}, nil, nil, "Vanilla", 0.4},
+		{"VanillaWithQuery", protocol.ServerDetail{VersionName: "1.20.4", QueryKV: map[string]string{"plugins": ""}}, nil, nil, "Vanilla", 0.55},
+		{"PaperFromQueryPlugins", protocol.ServerDetail{VersionName: "1.20.4", QueryKV: map[string]string{"plugins": "Paper on 1.20.4: WorldEdit 7.2.15"}}, nil, nil, "Paper", 0.9},
+		{"ForgeLoader", protocol.ServerDetail{VersionName: "1.20.1", ModLoader: protocol.ModLoaderForge}, nil, forgeLogin, "Forge", 0.95},
+		{"NeoForgeLoader", protocol.ServerDetail{VersionName: "1.20.4", ModLoader: protocol.ModLoaderNeoForge}, nil, nil, "NeoForge", 0.95},
+		{"ChatReportsAloneIsWeak", protocol.ServerDetail{VersionName: "1.20.4"}, chatReports, nil, "Vanilla", 0.4},
+		{"FabricName", protocol.ServerDetail{VersionName: "1.20.4", QueryKV: map[string]string{"server_mod": "Fabric"}}, chatReports, nil, "Fabric", 0.9},
+		{"VelocityBackend", protocol.ServerDetail{VersionName: "1.20.4"}, nil, velocityLogin, "Paper", 0.5},
+		{"UnknownQueryMod", protocol.ServerDetail{VersionName: "Glowstone 2022.6.0", QueryKV: map[string]string{"server_mod": "Glowstone"}}, nil, nil, "Glowstone", 0.5},
+		{"Geyser", protocol.ServerDetail{Edition: protocol.EditionBedrock, MOTD: "Geyser", LevelName: "Another Geyser server."}, nil, nil, "Geyser", 0.8},
+		{"BedrockDedicated", protocol.ServerDetail{Edition: protocol.EditionBedrock, MOTD: "Dedicated Server", LevelName: "Bedrock level"}, nil, nil, "Bedrock Dedicated Server", 0.6},
+		{"Nothing", protocol.ServerDetail{}, nil, nil, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conf := protocol.FingerprintSoftware(&tt.detail, tt.status, tt.login)
+			if got != tt.want || conf < tt.minConf || conf > 1 {
+				t.Errorf("FingerprintSoftware() = %q (%.2f), want %q (>= %.2f)", got, conf, tt.want, tt.minConf)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package protocol_test
+
+import (
+	"MinecraftCrawler/internal/mctest"
+	"MinecraftCrawler/internal/protocol"
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// encodeForgeString mirrors Forge's ServerStatusPing.encodeOptimized.
+func encodeForgeString(data []byte) string {
+	chars := []rune{rune(len(data) & 0x7FFF), rune((len(data) >> 15) & 0x7FFF)}
+	var buffer uint32
+	bits := 0
+	for _, b := range data {
+		if bits >= 15 {
+			chars = append(chars, rune(buffer&0x7FFF))
+			buffer >>= 15
+			bits -= 15
+		}
+		buffer |= uint32(b) << bits
+		bits += 8
+	}
+	if bits > 0 {
+		chars = append(chars, rune(buffer&0x7FFF))
+	}
+	return string(chars)
+}
+
+func writeForgeString(buf *bytes.Buffer, s string) {
+	_ = protocol.WriteVarInt(buf, len(s))
+	buf.WriteString(s)
+}
+
+type forgeTestMod struct {
+	id, version string
+	channels    []protocol.ForgeChannel
+}
+
+// forgeD builds the payload Forge 1.18+ puts in forgeData.d.
+func forgeD(truncated bool, mods []forgeTestMod, other []protocol.ForgeChannel) string {
+	buf := new(bytes.Buffer)
+	if truncated {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(mods)))
+	writeChannel := func(ch protocol.ForgeChannel) {
+		writeForgeString(buf, ch.Name)
+		writeForgeString(buf, ch.Version)
+		if ch.Required {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	}
+	for _, m := range mods {
+		flags := len(m.channels) << 1
+		if m.version == "" {
+			flags |= 1 // server-only mod, no version sent
+		}
+		_ = protocol.WriteVarInt(buf, flags)
+		writeForgeString(buf, m.id)
+		if m.version != "" {
+			writeForgeString(buf, m.version)
+		}
+		for _, ch := range m.channels {
+			writeChannel(ch)
+		}
+	}
+	_ = protocol.WriteVarInt(buf, len(other))
+	for _, ch := range other {
+		writeChannel(ch)
+	}
+	return encodeForgeString(buf.Bytes())
+}
+
+func TestDecodeForgeData(t *testing.T) {
+	d := forgeD(true, []forgeTestMod{
+		{id: "forge", version: "ANY"},
+		{id: "create", version: "0.5.1.f", channels: []protocol.ForgeChannel{{Name: "main", Version: "3", Required: true}}},
+		{id: "spark"},
+	}, []protocol.ForgeChannel{{Name: "minecraft:unregister", Version: "FML3"}})
+
+	fd, err := protocol.DecodeForgeData(d)
+	if err != nil {
+		t.Fatalf("DecodeForgeData failed: %v", err)
+	}
+	if !fd.Truncated || len(fd.Mods) != 3 {
+		t.Fatalf("decoded = %+v", fd)
+	}
+	if fd.Mods[1].ModID != "create" || fd.Mods[1].ModMarker != "0.5.1.f" || fd.Mods[2].ModMarker != "" {
+		t.Errorf("mods = %+v", fd.Mods)
+	}
+	want := []protocol.ForgeChannel{
+		{Name: "create:main", Version: "3", Required: true},
+		{Name: "minecraft:unregister", Version: "FML3"},
+	}
+	if len(fd.Channels) != 2 || fd.Channels[0] != want[0] || fd.Channels[1] != want[1] {
+		t.Errorf("channels = %+v, want %+v", fd.Channels, want)
+	}
+}
+
+func TestDecodeForgeDataRejectsShortInput(t *testing.T) {
+	full := forgeD(false, []forgeTestMod{{id: "forge", version: "ANY"}}, nil)
+	for _, d := range []string{"", full[:1], string([]rune(full)[:3])} {
+		if _, err := protocol.DecodeForgeData(d); err == nil {
+			t.Errorf("DecodeForgeData(%q) accepted a truncated payload", d)
+		}
+	}
+}
+
+func TestEndToEndModdedServers(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     map[string]interface{}
+		wantLoader string
+		wantMods   map[string]string
+		wantNet    int
+		truncated  bool
+		channels   int
+	}{
+		{
+			name: "FML1",
+			status: map[string]interface{}{
+				"version": map[string]interface{}{"name": "1.12.2", "protocol": 340},
+				"modinfo": map[string]interface{}{"type": "FML", "modList": []map[string]string{
+					{"modid": "minecraft", "version": "1.12.2"}, {"modid": "jei", "version": "4.16.1"},
+				}},
+			},
+			wantLoader: protocol.ModLoaderFML,
+			wantMods:   map[string]string{"minecraft": "1.12.2", "jei": "4.16.1"},
+			wantNet:    1,
+		},
+		{
+			name: "FML2",
+			status: map[string]interface{}{
+				"version": map[string]interface{}{"name": "1.16.5", "protocol": 754},
+				"forgeData": map[string]interface{}{
+					"fmlNetworkVersion": 2,
+					"mods":              []map[string]string{{"modId": "forge", "modmarker": "36.2.39"}},
+					"channels":          []map[string]interface{}{{"res": "forge:tier_sorting", "version": "1.0", "required": false}},
+				},
+			},
+			wantLoader: protocol.ModLoaderForge,
+			wantMods:   map[string]string{"forge": "36.2.39"},
+			wantNet:    2,
+			channels:   1,
+		},
+		{
+			name: "FML3",
+			status: map[string]interface{}{
+				"version": map[string]interface{}{"name": "1.20.1", "protocol": 763},
+				"forgeData": map[string]interface{}{
+					"fmlNetworkVersion": 3, "truncated": true, "mods": []interface{}{}, "channels": []interface{}{},
+					"d": forgeD(true, []forgeTestMod{
+						{id: "forge", version: "ANY"},
+						{id: "create", version: "0.5.1.f", channels: []protocol.ForgeChannel{{Name: "main", Version: "3"}}},
+					}, nil),
+				},
+			},
+			wantLoader: protocol.ModLoaderForge,
+			wantMods:   map[string]string{"forge": "ANY", "create": "0.5.1.f"},
+			wantNet:    3,
+			truncated:  true,
+			channels:   1,
+		},
+		{
+			name: "NeoForge",
+			status: map[string]interface{}{
+				"version":  map[string]interface{}{"name": "1.20.4", "protocol": 765},
+				"isModded": true,
+			},
+			wantLoader: protocol.ModLoaderNeoForge,
+			wantMods:   map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startServer(t, mctest.Config{Status: tt.status})
+			detail, err := protocol.AnalyzeServer(srv.Host, srv.Port, 2*time.Second)
+			if err != nil {
+				t.Fatalf("AnalyzeServer failed: %v", err)
+			}
+			if detail.ModLoader != tt.wantLoader || detail.FMLNetworkVersion != tt.wantNet || detail.ModsTruncated != tt.truncated {
+				t.Errorf("loader = %q, fml = %d, truncated = %v", detail.ModLoader, detail.FMLNetworkVersion, detail.ModsTruncated)
+			}
+			if len(detail.Mods) != len(tt.wantMods) {
+				t.Errorf("mods = %v, want %v", detail.Mods, tt.wantMods)
+			}
+			for id, v := range tt.wantMods {
+				if detail.Mods[id] != v {
+					t.Errorf("mods[%s] = %q, want %q", id, detail.Mods[id], v)
+				}
+			}
+			if len(detail.ModChannels) != tt.channels {
+				t.Errorf("channels = %+v", detail.ModChannels)
+			}
+		})
+	}
+}
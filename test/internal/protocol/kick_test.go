@@ -0,0 +1,94 @@
+package protocol_test
+
+import (
+	"MinecraftCrawler/internal/mctest"
+	"MinecraftCrawler/internal/protocol"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClassifyKick(t *testing.T) {
+	tests := []struct {
+		text string
+		want protocol.KickCategory
+	}{
+		{"You are not white-listed on this server!", protocol.KickWhitelist},
+		{"No estás en la lista blanca de este servidor", protocol.KickWhitelist},
+		{"Du bist nicht auf der Whitelist!", protocol.KickWhitelist},
+		{"Vous n'êtes pas sur la liste blanche", protocol.KickWhitelist},
+		{"You are banned from this server.\nReason: Griefing", protocol.KickBanned},
+		{"Has sido baneado permanentemente", protocol.KickBanned},
+		{"Du wurdest vom Netzwerk gebannt", protocol.KickBanned},
+		{"The server is currently under maintenance", protocol.KickMaintenance},
+		{"El servidor está en mantenimiento", protocol.KickMaintenance},
+		{"Wartungsarbeiten! Bitte versuche es später erneut.", protocol.KickMaintenance},
+		{"Incompatible client! Please use 1.20.4", protocol.KickOutdatedClient},
+		{"Outdated server! I'm still on 1.8.8", protocol.KickOutdatedClient},
+		{"Mod rejections: [jei]", protocol.KickModdedRequired},
+		{"This server has mods that require FML/Forge to be installed on the client.", protocol.KickModdedRequired},
+		{"Please disable your VPN to join", protocol.KickVPNBlocked},
+		{"Server is full!", protocol.KickFull},
+		{"¡El servidor está lleno!", protocol.KickFull},
+		{"If you wish to use IP forwarding, please enable it in your BungeeCord config as well!", protocol.KickProxyRequired},
+		{"Unable to authenticate - no data was forwarded by the proxy.", protocol.KickProxyRequired},
+		{"Goodbye", protocol.KickOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := protocol.ClassifyKick(protocol.ChatComponent{Text: tt.text}); got != tt.want {
+				t.Errorf("ClassifyKick(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyKickTranslated(t *testing.T) {
+	reason := protocol.ChatComponent{Translate: "multiplayer.disconnect.not_whitelisted"}
+	if got := protocol.ClassifyKick(reason); got != protocol.KickWhitelist {
+		t.Errorf("ClassifyKick(not_whitelisted) = %q", got)
+	}
+}
+
+func TestParseKickRules(t *testing.T) {
+	rules, err := protocol.ParseKickRules(strings.NewReader("# comment\n\nmaintenance  cerrado por obras\nfull\tsin hueco\n"))
+	if err != nil {
+		t.Fatalf("ParseKickRules failed: %v", err)
+	}
+	if len(rules) != 2 || rules[1].Category != protocol.KickFull {
+		t.Fatalf("rules = %+v", rules)
+	}
+
+	c := protocol.NewKickClassifier(append(rules, protocol.BuiltinKickRules()...))
+	if got := c.Classify("Cerrado por obras, vuelve mañana"); got != protocol.KickMaintenance {
+		t.Errorf("custom rule not applied (case-insensitive): %q", got)
+	}
+	if got := c.Classify("Server is full!"); got != protocol.KickFull {
+		t.Errorf("built-in rules lost: %q", got)
+	}
+
+	for _, bad := range []string{"whitelist", "unknown foo", "banned (unclosed"} {
+		if _, err := protocol.ParseKickRules(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseKickRules(%q) accepted an invalid rule", bad)
+		}
+	}
+}
+
+func TestAnalyzeServerStoresKick(t *testing.T) {
+	srv := startServer(t, mctest.Config{
+		Status:          modernStatus(),
+		LoginDisconnect: `{"text":"","extra":[{"text":"Du bist nicht auf der ","color":"red"},{"text":"Whitelist"}]}`,
+	})
+
+	detail, err := protocol.AnalyzeServer(srv.Host, srv.Port, 2*time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeServer failed: %v", err)
+	}
+	if detail.KickReason != "Du bist nicht auf der Whitelist" || detail.KickCategory != protocol.KickWhitelist {
+		t.Errorf("kick = %q (%q)", detail.KickReason, detail.KickCategory)
+	}
+	if !detail.IsWhitelist || detail.LoginOutcome != protocol.LoginWhitelisted {
+		t.Errorf("whitelist = %v, outcome = %q", detail.IsWhitelist, detail.LoginOutcome)
+	}
+}
@@ -0,0 +1,167 @@
+package protocol_test
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+func TestParseLegacyResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    protocol.LegacyStatus
+		wantErr bool
+	}{
+		{
+			name:  "Modern1.4Format",
+			input: "§1\x0078\x001.6.4\x00A §aLegacy§r Server\x003\x0020",
+			want:  protocol.LegacyStatus{Protocol: 78, VersionName: "1.6.4", MOTD: "A §aLegacy§r Server", PlayersOnline: 3, PlayersMax: 20},
+		},
+		{
+			name:  "BetaFormat",
+			input: "A Beta Server§5§10",
+			want:  protocol.LegacyStatus{VersionName: "Beta 1.8-1.3", MOTD: "A Beta Server", PlayersOnline: 5, PlayersMax: 10},
+		},
+		{
+			name:  "BetaFormatWithColors",
+			input: "§cRed§r MOTD§0§8",
+			want:  protocol.LegacyStatus{VersionName: "Beta 1.8-1.3", MOTD: "§cRed§r MOTD", PlayersOnline: 0, PlayersMax: 8},
+		},
+		{"Garbage", "hello", protocol.LegacyStatus{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := protocol.ParseLegacyResponse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLegacyResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && *got != tt.want {
+				t.Errorf("ParseLegacyResponse() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+// mockLegacyServer answers every connection with a 0xFF kick packet, the way
+// a 1.6 server does for both legacy pings and modern handshakes.
+func mockLegacyServer(t *testing.T, response string) (string, int, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	units := utf16.Encode([]rune(response))
+	packet := new(bytes.Buffer)
+	packet.WriteByte(0xFF)
+	_ = binary.Write(packet, binary.BigEndian, uint16(len(units)))
+	_ = binary.Write(packet, binary.BigEndian, units)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, 512)
+			_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+			_, _ = conn.Read(buf)
+			_, _ = conn.Write(packet.Bytes())
+			conn.Close()
+		}
+	}()
+
+	host, portStr, _ := net.SplitHostPort(l.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	return host, port, func() { l.Close() }
+}
+
+func TestAnalyzeServerLegacyFallback(t *testing.T) {
+	host, port, cleanup := mockLegacyServer(t, "§1\x0078\x001.6.4\x00§6Old School\x001\x0010")
+	defer cleanup()
+
+	detail, err := protocol.AnalyzeServer(host, port, 2*time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeServer failed: %v", err)
+	}
+	if detail.VersionName != "1.6.4" || detail.Protocol != 78 {
+		t.Errorf("version = %s (%d), want 1.6.4 (78)", detail.VersionName, detail.Protocol)
+	}
+	if detail.MOTD != "Old School" {
+		t.Errorf("MOTD = %q, want %q", detail.MOTD, "Old School")
+	}
+	if detail.PlayersOnline != 1 || detail.PlayersMax != 10 {
+		t.Errorf("players = %d/%d, want 1/10", detail.PlayersOnline, detail.PlayersMax)
+	}
+}
+
+func TestAnalyzeServerNonMinecraftStopsLegacy(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	// Answers every request with a banner and hangs up, like an SSH server
+	var conns atomic.Int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns.Add(1)
+			buf := make([]byte, 512)
+			_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+			_, _ = conn.Read(buf)
+			_, _ = conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+			conn.Close()
+		}
+	}()
+
+	host, portStr, _ := net.SplitHostPort(l.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	if _, err := protocol.AnalyzeServer(host, port, time.Second); err == nil {
+		t.Fatal("AnalyzeServer succeeded against a non-Minecraft service")
+	}
+	if n := conns.Load(); n != 2 {
+		t.Errorf("connections = %d, want 2 (status ping and one legacy ping)", n)
+	}
+}
+
+func TestAnalyzeServerSilentPortSkipsLegacy(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	// Accepts and never answers, like a filtered or stalled service
+	var conns atomic.Int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns.Add(1)
+			defer conn.Close()
+		}
+	}()
+
+	host, portStr, _ := net.SplitHostPort(l.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	if _, err := protocol.AnalyzeServer(host, port, 200*time.Millisecond); err == nil {
+		t.Fatal("AnalyzeServer succeeded against a silent port")
+	}
+	if n := conns.Load(); n != 1 {
+		t.Errorf("connections = %d, want 1 (no legacy fallback after a timeout)", n)
+	}
+}
@@ -0,0 +1,86 @@
+package protocol_test
+
+import (
+	"MinecraftCrawler/internal/mctest"
+	"MinecraftCrawler/internal/protocol"
+	"testing"
+	"time"
+)
+
+func TestProbeLogin(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  mctest.Config
+		want protocol.LoginOutcome
+	}{
+		{"OnlineMode", mctest.Config{OnlineMode: true}, protocol.LoginOnline},
+		{"Cracked", mctest.Config{}, protocol.LoginOffline},
+		{"CrackedWithCompression", mctest.Config{CompressionThreshold: 256}, protocol.LoginOffline},
+		{"Whitelisted", mctest.Config{LoginDisconnect: `{"translate":"multiplayer.disconnect.not_whitelisted"}`}, protocol.LoginWhitelisted},
+		{"Banned", mctest.Config{LoginDisconnect: `{"translate":"multiplayer.disconnect.banned.reason","with":["Griefing"]}`}, protocol.LoginBanned},
+		{"ServerFull", mctest.Config{LoginDisconnect: `{"translate":"multiplayer.disconnect.server_full"}`}, protocol.LoginKicked},
+		{"Forge", mctest.Config{
+			LoginChannel:    "fml:loginwrapper",
+			LoginDisconnect: `"This server has mods that require FML/Forge to be installed on the client."`,
+		}, protocol.LoginModdedRequired},
+		{"VelocityBackend", mctest.Config{
+			LoginChannel:    "velocity:player_info",
+			LoginDisconnect: `{"text":"This server requires you to connect with Velocity."}`,
+		}, protocol.LoginProxyRejected},
+		{"VelocityCustomKick", mctest.Config{
+			LoginChannel:    "velocity:player_info",
+			LoginDisconnect: `{"text":"Please join through our network."}`,
+		}, protocol.LoginProxyRejected},
+		{"WhitelistedAfterCompression", mctest.Config{
+			CompressionThreshold: 256,
+			LoginDisconnect:      `{"text":"You are not whitelisted on this server!"}`,
+		}, protocol.LoginWhitelisted},
+		{"CompressedKickAboveThreshold", mctest.Config{
+			CompressionThreshold: 16,
+			LoginDisconnect:      `{"text":"You are banned from this server.\nReason: Griefing the spawn area"}`,
+		}, protocol.LoginBanned},
+		{"PluginChannelThenSuccess", mctest.Config{LoginChannel: "example:hello"}, protocol.LoginOffline},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startServer(t, tt.cfg)
+			probe, err := protocol.ProbeLogin(srv.Host, srv.Port, 765, time.Second)
+			if err != nil {
+				t.Fatalf("ProbeLogin failed: %v", err)
+			}
+			if probe.Outcome != tt.want {
+				t.Errorf("outcome = %q, want %q (reason %q)", probe.Outcome, tt.want, probe.Reason.PlainText())
+			}
+		})
+	}
+}
+
+// A server in maintenance reports protocol -1; the login probe must still
+// finish, announcing the status protocol instead.
+func TestAnalyzeServerNegativeProtocol(t *testing.T) {
+	srv := startServer(t, mctest.Config{Status: statusNamed("Maintenance", -1, "Back soon")})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := protocol.AnalyzeServer(srv.Host, srv.Port, time.Second)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("AnalyzeServer failed: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("AnalyzeServer hung on a status with protocol -1")
+	}
+
+	for _, hs := range srv.Handshakes() {
+		if hs.NextState == 2 && hs.Protocol <= 0 {
+			t.Errorf("login handshake announced protocol %d", hs.Protocol)
+		}
+	}
+	if len(srv.Logins()) == 0 {
+		t.Error("login probe was not attempted")
+	}
+}
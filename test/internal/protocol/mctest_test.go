@@ -0,0 +1,202 @@
+package protocol_test
+
+import (
+	"MinecraftCrawler/internal/mctest"
+	"MinecraftCrawler/internal/protocol"
+	"testing"
+	"time"
+)
+
+func startServer(t *testing.T, cfg mctest.Config) *mctest.Server {
+	t.Helper()
+	srv, err := mctest.Start(cfg)
+	if err != nil {
+		t.Fatalf("mctest.Start failed: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func modernStatus() protocol.StatusResponse {
+	var status protocol.StatusResponse
+	status.Version.Name = "Paper 1.20.4"
+	status.Version.Protocol = 765
+	status.Players.Online = 3
+	status.Players.Max = 50
+	status.Description = map[string]interface{}{"text": "Hello ", "extra": []interface{}{map[string]interface{}{"text": "world", "color": "gold"}}}
+	return status
+}
+
+func TestEndToEndWhitelistedServerWithQuery(t *testing.T) {
+	srv := startServer(t, mctest.Config{
+		Status:          modernStatus(),
+		LoginDisconnect: `{"text":"You are not whitelisted on this server!"}`,
+		Query: &mctest.Query{
+			KV: map[string]string{
+				"hostname":   "Hello world",
+				"server_mod": "Paper on 1.20.4",
+				"plugins":    "Paper on 1.20.4: WorldEdit 7.2.15; Essentials 2.20",
+				"map":        "world",
+			},
+			Players: []string{"Notch", "jeb_"},
+		},
+	})
+
+	detail, err := protocol.AnalyzeServer(srv.Host, srv.Port, 2*time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeServer failed: %v", err)
+	}
+	if detail.VersionName != "Paper 1.20.4" || detail.Protocol != 765 {
+		t.Errorf("version = %s (%d)", detail.VersionName, detail.Protocol)
+	}
+	if detail.MOTD != "Hello world" || detail.PlayersOnline != 3 || detail.PlayersMax != 50 {
+		t.Errorf("motd/players = %q %d/%d", detail.MOTD, detail.PlayersOnline, detail.PlayersMax)
+	}
+	if !detail.IsWhitelist || detail.LoginOutcome != protocol.LoginWhitelisted {
+		t.Errorf("whitelist kick not detected: %q", detail.LoginOutcome)
+	}
+	if detail.Software != "Paper" || detail.SoftwareConfidence < 0.9 {
+		t.Errorf("software = %q (%.2f), want Paper from the version and query", detail.Software, detail.SoftwareConfidence)
+	}
+	if len(detail.Plugins) != 2 || detail.Plugins[0] != "WorldEdit 7.2.15" {
+		t.Errorf("plugins = %v", detail.Plugins)
+	}
+	if len(detail.QueryPlayers) != 2 || detail.QueryPlayers[0] != "Notch" || detail.QueryPlayers[1] != "jeb_" {
+		t.Errorf("query players = %v", detail.QueryPlayers)
+	}
+	if detail.QueryKV["hostname"] != "Hello world" || detail.LevelName != "world" {
+		t.Errorf("query kv = %v, level = %q", detail.QueryKV, detail.LevelName)
+	}
+
+	hs := srv.Handshakes()
+	if len(hs) != 2 || hs[0].NextState != 1 || hs[1].NextState != 2 || hs[1].Protocol != 765 {
+		t.Errorf("handshakes = %+v, want status then login with the server protocol", hs)
+	}
+	if logins := srv.Logins(); len(logins) != 1 {
+		t.Errorf("logins = %v", logins)
+	}
+}
+
+func TestEndToEndOnlineModeServer(t *testing.T) {
+	srv := startServer(t, mctest.Config{Status: modernStatus(), OnlineMode: true})
+
+	detail, err := protocol.AnalyzeServer(srv.Host, srv.Port, 2*time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeServer failed: %v", err)
+	}
+	if detail.IsWhitelist {
+		t.Error("encryption request reported as whitelist")
+	}
+	if detail.LoginOutcome != protocol.LoginOnline {
+		t.Errorf("login outcome = %q, want online", detail.LoginOutcome)
+	}
+}
+
+func TestEndToEndLegacyServer(t *testing.T) {
+	srv := startServer(t, mctest.Config{
+		Legacy: &protocol.LegacyStatus{Protocol: 78, VersionName: "1.6.4", MOTD: "§aRetro", PlayersOnline: 2, PlayersMax: 20},
+	})
+
+	detail, err := protocol.AnalyzeServer(srv.Host, srv.Port, 2*time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeServer failed: %v", err)
+	}
+	if detail.VersionName != "1.6.4" || detail.MOTD != "Retro" || detail.PlayersOnline != 2 {
+		t.Errorf("detail = %+v", detail)
+	}
+
+	beta, err := protocol.LegacyPing(srv.Host, srv.Port, time.Second, protocol.LegacyPingBeta)
+	if err != nil {
+		t.Fatalf("beta ping failed: %v", err)
+	}
+	if beta.MOTD != "§aRetro" || beta.PlayersMax != 20 {
+		t.Errorf("beta status = %+v", beta)
+	}
+}
+
+func TestEndToEndLegacyServerAnnouncesHostname(t *testing.T) {
+	srv := startServer(t, mctest.Config{
+		Legacy: &protocol.LegacyStatus{Protocol: 78, VersionName: "1.6.4", MOTD: "Retro", PlayersMax: 20},
+	})
+
+	detail, err := protocol.AnalyzeServerHost(srv.Host, srv.Port, "retro.example.net", 2*time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeServerHost failed: %v", err)
+	}
+	if detail.VersionName != "1.6.4" || detail.Hostname != "retro.example.net" {
+		t.Errorf("detail = %+v", detail)
+	}
+	if hosts := srv.LegacyPingHosts(); len(hosts) != 1 || hosts[0] != "retro.example.net" {
+		t.Errorf("MC|PingHost hosts = %q, want [retro.example.net]", hosts)
+	}
+}
+
+func TestEndToEndModernServerAnswersLegacyPing(t *testing.T) {
+	srv := startServer(t, mctest.Config{Status: modernStatus()})
+
+	status, err := protocol.GetLegacyStatus(srv.Host, srv.Port, time.Second)
+	if err != nil {
+		t.Fatalf("GetLegacyStatus failed: %v", err)
+	}
+	if status.VersionName != "Paper 1.20.4" || status.PlayersOnline != 3 {
+		t.Errorf("legacy status = %+v", status)
+	}
+}
+
+func TestEndToEndQuery(t *testing.T) {
+	srv := startServer(t, mctest.Config{
+		Query: &mctest.Query{KV: map[string]string{"server_mod": "Vanilla", "map": "survival"}},
+	})
+
+	res, err := protocol.GetQueryInfo(srv.Host, srv.Port, time.Second)
+	if err != nil {
+		t.Fatalf("GetQueryInfo failed: %v", err)
+	}
+	if res.Software != "Vanilla" || res.MapName != "survival" {
+		t.Errorf("query = %+v", res)
+	}
+}
+
+func TestEndToEndRcon(t *testing.T) {
+	srv := startServer(t, mctest.Config{Status: modernStatus(), Rcon: &mctest.Rcon{Password: "hunter2"}})
+
+	detail, err := protocol.DefaultRegistry.Analyze(protocol.EditionJava, srv.Host, srv.RconPort, time.Second)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if !detail.RconOpen {
+		t.Error("RCON listener not detected")
+	}
+
+	ok, err := protocol.ProbeRcon(srv.Host, srv.RconPort, time.Second, "hunter2")
+	if err != nil || !ok {
+		t.Errorf("correct password: ok=%t err=%v", ok, err)
+	}
+
+	// The game port must not be mistaken for RCON
+	detail, err = protocol.DefaultRegistry.Analyze(protocol.EditionJava, srv.Host, srv.Port, time.Second)
+	if err != nil {
+		t.Fatalf("Analyze game port failed: %v", err)
+	}
+	if detail.RconOpen || detail.VersionName != "Paper 1.20.4" {
+		t.Errorf("game port detail = %+v", detail)
+	}
+}
+
+func TestEndToEndPlayerSample(t *testing.T) {
+	status := modernStatus()
+	status.Players.Sample = []protocol.PlayerSample{
+		{Name: "Notch", ID: "069a79f4-44e9-4726-a5be-fca90e38aaf5"},
+		{Name: "§6Welcome to the server!", ID: "00000000-0000-0000-0000-000000000000"},
+		{Name: "jeb_", ID: "853c80ef-3c37-49fd-aa49-938b674adae6"},
+	}
+	srv := startServer(t, mctest.Config{Status: status})
+
+	detail, err := protocol.AnalyzeServer(srv.Host, srv.Port, 2*time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeServer failed: %v", err)
+	}
+	if len(detail.PlayerSample) != 2 || detail.PlayerSample[0].Name != "Notch" || detail.PlayerSample[1].Name != "jeb_" {
+		t.Errorf("sample = %+v, want the two real players", detail.PlayerSample)
+	}
+}
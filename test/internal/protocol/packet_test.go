@@ -0,0 +1,62 @@
+package protocol_test
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPacketConnRoundTrip(t *testing.T) {
+	small := []byte{0x00, 0x01, 0x02}
+	large := append([]byte{0x05}, []byte(strings.Repeat("compressible ", 100))...)
+
+	tests := []struct {
+		name      string
+		threshold int
+	}{
+		{"Uncompressed", -1},
+		{"ThresholdZero", 0},
+		{"Threshold256", 256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			pc := protocol.NewPacketConn(&buf)
+			pc.SetCompression(tt.threshold)
+
+			for _, payload := range [][]byte{small, large} {
+				if err := pc.WritePacket(payload); err != nil {
+					t.Fatalf("WritePacket failed: %v", err)
+				}
+			}
+			if tt.threshold >= 0 && buf.Len() >= len(small)+len(large) {
+				t.Errorf("wire size %d, large packet was not compressed", buf.Len())
+			}
+
+			for _, payload := range [][]byte{small, large} {
+				id, body, err := pc.ReadPacket()
+				if err != nil {
+					t.Fatalf("ReadPacket failed: %v", err)
+				}
+				rest, _ := io.ReadAll(body)
+				if id != int(payload[0]) || !bytes.Equal(rest, payload[1:]) {
+					t.Errorf("ReadPacket() = 0x%02x %q, want 0x%02x %q", id, rest, payload[0], payload[1:])
+				}
+			}
+		})
+	}
+}
+
+func TestPacketConnRejectsCorruptCompression(t *testing.T) {
+	// Frame of 4 bytes: uncompressed length 100 followed by data that is not zlib
+	frame := []byte{0x04, 100, 0xDE, 0xAD, 0xBE}
+	pc := protocol.NewPacketConn(bytes.NewBuffer(frame))
+	pc.SetCompression(64)
+
+	if _, _, err := pc.ReadPacket(); err == nil {
+		t.Error("ReadPacket accepted a corrupt compressed frame")
+	}
+}
@@ -0,0 +1,129 @@
+package protocol_test
+
+import (
+	"MinecraftCrawler/internal/mctest"
+	"MinecraftCrawler/internal/protocol"
+	"testing"
+	"time"
+)
+
+func statusNamed(name string, protocolVersion int, motd string) protocol.StatusResponse {
+	var status protocol.StatusResponse
+	status.Version.Name = name
+	status.Version.Protocol = protocolVersion
+	status.Description = motd
+	return status
+}
+
+func TestEndToEndProxyDetection(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         mctest.Config
+		wantType    string
+		wantMin     int
+		wantMax     int
+		hostRouting bool
+	}{
+		{
+			name: "Velocity",
+			cfg: mctest.Config{
+				Status:         statusNamed("Velocity 3.3.0", 769, "A network"),
+				ProxyProtocols: [2]int{47, 769},
+			},
+			wantType: protocol.ProxyVelocity, wantMin: 47, wantMax: 769,
+		},
+		{
+			name: "BungeeCordForcedHosts",
+			cfg: mctest.Config{
+				Status:         statusNamed("BungeeCord 1.8.x-1.21.x", 767, "Lobby"),
+				HostStatus:     map[string]interface{}{"127.0.0.1": statusNamed("BungeeCord 1.8.x-1.21.x", 767, "Connect through play.example.net")},
+				ProxyProtocols: [2]int{47, 767},
+			},
+			wantType: protocol.ProxyBungeeCord, wantMin: 47, wantMax: 767, hostRouting: true,
+		},
+		{
+			name: "UnnamedHostRouting",
+			cfg: mctest.Config{
+				Status:         statusNamed("1.20.4", 765, "Default"),
+				HostStatus:     map[string]interface{}{"127.0.0.1": statusNamed("1.20.4", 765, "Use our domain")},
+				ProxyProtocols: [2]int{393, 765},
+			},
+			wantType: protocol.ProxyUnknown, wantMin: 393, wantMax: 765, hostRouting: true,
+		},
+		{
+			name: "ViaVersionBackend",
+			cfg: mctest.Config{
+				Status:         statusNamed("Paper 1.20.4", 765, "Survival"),
+				ProxyProtocols: [2]int{107, 765},
+			},
+			wantType: "", wantMin: 107, wantMax: 765,
+		},
+		{
+			name: "Plain1201",
+			cfg:  mctest.Config{Status: statusNamed("Paper 1.20.1", 763, "Survival")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startServer(t, tt.cfg)
+			detail, err := protocol.AnalyzeServer(srv.Host, srv.Port, 2*time.Second)
+			if err != nil {
+				t.Fatalf("AnalyzeServer failed: %v", err)
+			}
+			if detail.ProxyType != tt.wantType || detail.ProtocolMin != tt.wantMin || detail.ProtocolMax != tt.wantMax {
+				t.Errorf("proxy = %q %d-%d, want %q %d-%d",
+					detail.ProxyType, detail.ProtocolMin, detail.ProtocolMax, tt.wantType, tt.wantMin, tt.wantMax)
+			}
+			if detail.HostRouting != tt.hostRouting {
+				t.Errorf("host routing = %v, want %v", detail.HostRouting, tt.hostRouting)
+			}
+		})
+	}
+}
+
+func TestProxyProbeCostForPlainServers(t *testing.T) {
+	srv := startServer(t, mctest.Config{Status: modernStatus()})
+	if _, err := protocol.AnalyzeServer(srv.Host, srv.Port, 2*time.Second); err != nil {
+		t.Fatalf("AnalyzeServer failed: %v", err)
+	}
+	// Status and login only: a server that does not echo the protocol is not re-probed
+	if hs := srv.Handshakes(); len(hs) != 2 {
+		t.Errorf("handshakes = %d, want 2", len(hs))
+	}
+}
+
+func TestEndToEndGeyserHybrid(t *testing.T) {
+	srv := startServer(t, mctest.Config{
+		Status: modernStatus(),
+		Query:  &mctest.Query{KV: map[string]string{"plugins": "Paper on 1.20.4: Geyser-Spigot 2.2.0; floodgate 2.2.2"}},
+	})
+	detail, err := protocol.AnalyzeServer(srv.Host, srv.Port, 2*time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeServer failed: %v", err)
+	}
+	if !detail.GeyserHybrid || detail.ProxyType != "" {
+		t.Errorf("geyser = %v, proxy = %q", detail.GeyserHybrid, detail.ProxyType)
+	}
+}
+
+func TestAnalyzeServerHostSendsHostname(t *testing.T) {
+	srv := startServer(t, mctest.Config{
+		Status:     statusNamed("Velocity 3.3.0", 765, "Lobby"),
+		HostStatus: map[string]interface{}{"survival.example.net": statusNamed("Velocity 3.3.0", 765, "Survival")},
+	})
+	detail, err := protocol.AnalyzeServerHost(srv.Host, srv.Port, "survival.example.net", 2*time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeServerHost failed: %v", err)
+	}
+	if detail.MOTD != "Survival" || detail.Hostname != "survival.example.net" {
+		t.Errorf("motd = %q, hostname = %q", detail.MOTD, detail.Hostname)
+	}
+	// Status, proxy probes and login all announce the domain except the
+	// deliberate unknown-host check.
+	for _, hs := range srv.Handshakes() {
+		if hs.Host != "survival.example.net" && hs.Host != "mccrawler.invalid" {
+			t.Errorf("handshake announced %q", hs.Host)
+		}
+	}
+}
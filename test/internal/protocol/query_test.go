@@ -81,3 +81,47 @@ func TestGetQueryInfo_Mock(t *testing.T) {
 		t.Errorf("expected map world, got %s", res.MapName)
 	}
 }
+
+func TestParseFullStat(t *testing.T) {
+	resp := []byte("\x00\x01\x01\x01\x01splitnum\x00\x80\x00" +
+		"hostname\x00A Server\x00gametype\x00SMP\x00game_id\x00MINECRAFT\x00version\x001.20.4\x00" +
+		"plugins\x00\x00map\x00world\x00numplayers\x002\x00maxplayers\x0020\x00hostport\x0025565\x00hostip\x00127.0.0.1\x00\x00" +
+		"\x01player_\x00\x00Notch\x00jeb_\x00\x00")
+
+	res, err := protocol.ParseFullStat(resp)
+	if err != nil {
+		t.Fatalf("ParseFullStat failed: %v", err)
+	}
+	want := map[string]string{
+		"hostname": "A Server", "gametype": "SMP", "game_id": "MINECRAFT", "version": "1.20.4", "plugins": "",
+		"map": "world", "numplayers": "2", "maxplayers": "20", "hostport": "25565", "hostip": "127.0.0.1",
+	}
+	if len(res.RawKV) != len(want) {
+		t.Errorf("RawKV = %v, want %v", res.RawKV, want)
+	}
+	for k, v := range want {
+		if res.RawKV[k] != v {
+			t.Errorf("RawKV[%s] = %q, want %q", k, res.RawKV[k], v)
+		}
+	}
+	if len(res.Players) != 2 || res.Players[0] != "Notch" || res.Players[1] != "jeb_" {
+		t.Errorf("Players = %v", res.Players)
+	}
+	if res.MapName != "world" {
+		t.Errorf("MapName = %q", res.MapName)
+	}
+}
+
+func TestParseFullStatEmptyPlayers(t *testing.T) {
+	res, err := protocol.ParseFullStat([]byte("\x00\x00\x00\x00\x01splitnum\x00\x80\x00numplayers\x000\x00\x00\x01player_\x00\x00\x00"))
+	if err != nil {
+		t.Fatalf("ParseFullStat failed: %v", err)
+	}
+	if len(res.Players) != 0 || res.RawKV["numplayers"] != "0" {
+		t.Errorf("result = %+v", res)
+	}
+
+	if _, err := protocol.ParseFullStat([]byte{0x09, 0x00}); err == nil {
+		t.Error("expected error for a non-stat packet")
+	}
+}
@@ -0,0 +1,140 @@
+package protocol_test
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// rconReply builds the packets a mock server sends after reading the auth request.
+type rconReply func(req protocol.RconPacket) [][]byte
+
+// rconAuthFailed is what vanilla and Source servers answer to a wrong password.
+func rconAuthFailed(req protocol.RconPacket) [][]byte {
+	return [][]byte{protocol.RconPacket{ID: -1, Type: protocol.RconTypeAuthResponse}.Encode()}
+}
+
+// rconAuthAccepted mimics a Source server: an empty RESPONSE_VALUE first, then the auth response.
+func rconAuthAccepted(req protocol.RconPacket) [][]byte {
+	return [][]byte{
+		protocol.RconPacket{ID: req.ID, Type: protocol.RconTypeResponseValue}.Encode(),
+		protocol.RconPacket{ID: req.ID, Type: protocol.RconTypeAuthResponse}.Encode(),
+	}
+}
+
+func mockRconServer(t *testing.T, reply rconReply) (string, int, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(time.Second))
+				req, err := protocol.ReadRconPacket(conn)
+				if err != nil {
+					return
+				}
+				for _, pkt := range reply(req) {
+					_, _ = conn.Write(pkt)
+				}
+			}(conn)
+		}
+	}()
+
+	host, portStr, _ := net.SplitHostPort(l.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	return host, port, func() { l.Close() }
+}
+
+func TestRconPacketRoundTrip(t *testing.T) {
+	pkt := protocol.RconPacket{ID: 42, Type: protocol.RconTypeExecCommand, Body: "list"}
+	encoded := pkt.Encode()
+	if size := binary.LittleEndian.Uint32(encoded); size != uint32(len("list")+10) {
+		t.Errorf("size = %d, want %d", size, len("list")+10)
+	}
+
+	got, err := protocol.ReadRconPacket(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("ReadRconPacket failed: %v", err)
+	}
+	if got != pkt {
+		t.Errorf("got %+v, want %+v", got, pkt)
+	}
+}
+
+func TestReadRconPacketRejectsGarbage(t *testing.T) {
+	tests := map[string][]byte{
+		"TooShort":      {0x02, 0x00, 0x00, 0x00, 0x00, 0x00},
+		"TooLong":       {0xFF, 0xFF, 0x00, 0x00},
+		"HTTPResponse":  []byte("HTTP/1.1 400 Bad Request\r\n\r\n"),
+		"NotTerminated": append([]byte{0x0A, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0}, 'x', 'y'),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := protocol.ReadRconPacket(bytes.NewReader(data)); !errors.Is(err, protocol.ErrNotDetected) {
+				t.Errorf("err = %v, want ErrNotDetected", err)
+			}
+		})
+	}
+}
+
+func TestProbeRcon(t *testing.T) {
+	host, port, cleanup := mockRconServer(t, rconAuthFailed)
+	defer cleanup()
+	ok, err := protocol.ProbeRcon(host, port, time.Second, "")
+	if err != nil || ok {
+		t.Errorf("auth failure: ok=%t err=%v, want rejected RCON", ok, err)
+	}
+
+	host, port, cleanup2 := mockRconServer(t, rconAuthAccepted)
+	defer cleanup2()
+	ok, err = protocol.ProbeRcon(host, port, time.Second, "")
+	if err != nil || !ok {
+		t.Errorf("auth success: ok=%t err=%v", ok, err)
+	}
+}
+
+func TestProbeRconWrongRequestID(t *testing.T) {
+	host, port, cleanup := mockRconServer(t, func(req protocol.RconPacket) [][]byte {
+		return [][]byte{protocol.RconPacket{ID: req.ID + 1, Type: protocol.RconTypeAuthResponse}.Encode()}
+	})
+	defer cleanup()
+
+	if _, err := protocol.ProbeRcon(host, port, time.Second, ""); !errors.Is(err, protocol.ErrNotDetected) {
+		t.Errorf("err = %v, want ErrNotDetected", err)
+	}
+}
+
+func TestAnalyzeRcon(t *testing.T) {
+	host, port, cleanup := mockRconServer(t, rconAuthFailed)
+	defer cleanup()
+
+	detail, err := protocol.AnalyzeRcon(host, port, time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeRcon failed: %v", err)
+	}
+	if !detail.RconOpen || detail.Port != port {
+		t.Errorf("detail = %+v", detail)
+	}
+}
+
+func TestAnalyzeRconRejectsOtherProtocols(t *testing.T) {
+	host, port, cleanup := mockLegacyServer(t, "§1\x0078\x001.6.4\x00MOTD\x000\x0010")
+	defer cleanup()
+
+	if _, err := protocol.AnalyzeRcon(host, port, time.Second); err == nil {
+		t.Error("legacy Minecraft server detected as RCON")
+	}
+}
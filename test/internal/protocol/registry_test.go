@@ -0,0 +1,137 @@
+package protocol_test
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The default registry must recognise RCON on any port, not only 25575.
+func TestDefaultRegistryDetectsRconOnAnyPort(t *testing.T) {
+	host, port, cleanup := mockRconServer(t, rconAuthFailed)
+	defer cleanup()
+
+	detail, err := protocol.DefaultRegistry.Analyze(protocol.EditionJava, host, port, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if !detail.RconOpen {
+		t.Errorf("RCON not detected on port %d", port)
+	}
+}
+
+func TestRegistryRouting(t *testing.T) {
+	var tried []string
+	analyzer := func(name string, err error) protocol.Analyzer {
+		return protocol.Analyzer{Name: name, Edition: protocol.EditionJava, Analyze: func(ip string, port int, _ time.Duration) (*protocol.ServerDetail, error) {
+			tried = append(tried, name)
+			if err != nil {
+				return nil, err
+			}
+			return &protocol.ServerDetail{IP: ip, Port: port, Software: name}, nil
+		}}
+	}
+
+	reg := protocol.NewRegistry(analyzer("first", protocol.ErrNotDetected))
+	reg.Register(analyzer("second", nil))
+	reg.Register(analyzer("third", nil))
+	reg.Register(protocol.Analyzer{Name: "bedrock", Edition: protocol.EditionBedrock})
+
+	detail, err := reg.Analyze(protocol.EditionJava, "10.0.0.1", 25570, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Software != "second" || len(tried) != 2 {
+		t.Errorf("software = %s, tried = %v", detail.Software, tried)
+	}
+
+	tried = nil
+	reg = protocol.NewRegistry(
+		analyzer("closed", &net.OpError{Op: "dial", Err: errors.New("connection refused")}),
+		analyzer("never", nil),
+	)
+	if _, err := reg.Analyze(protocol.EditionJava, "10.0.0.1", 25565, time.Second); err == nil {
+		t.Error("expected dial error")
+	}
+	if len(tried) != 1 {
+		t.Errorf("analyzers tried after a dial error: %v", tried)
+	}
+
+	if _, err := reg.Analyze("unknown", "10.0.0.1", 25565, time.Second); err == nil {
+		t.Error("expected error for edition without analyzers")
+	}
+}
+
+// Analyzers that list the port among their usual ones go first; elsewhere
+// the registration order is kept.
+func TestRegistryPortHints(t *testing.T) {
+	var tried []string
+	analyzer := func(name string, ports ...int) protocol.Analyzer {
+		return protocol.Analyzer{Name: name, Edition: protocol.EditionJava, Ports: ports, Analyze: func(string, int, time.Duration) (*protocol.ServerDetail, error) {
+			tried = append(tried, name)
+			return nil, protocol.ErrNotDetected
+		}}
+	}
+	reg := protocol.NewRegistry(analyzer("java"), analyzer("rcon", 25575), analyzer("other"))
+
+	tests := []struct {
+		port int
+		want string
+	}{
+		{25575, "rcon,java,other"},
+		{25565, "java,rcon,other"},
+	}
+	for _, tt := range tests {
+		tried = nil
+		if _, err := reg.Analyze(protocol.EditionJava, "10.0.0.1", tt.port, time.Second); !errors.Is(err, protocol.ErrNotDetected) {
+			t.Errorf("port %d: err = %v, want ErrNotDetected", tt.port, err)
+		}
+		if got := strings.Join(tried, ","); got != tt.want {
+			t.Errorf("port %d: tried %s, want %s", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestRegistryAnalyzeHost(t *testing.T) {
+	var gotHost string
+	reg := protocol.NewRegistry(
+		protocol.Analyzer{
+			Name: "java", Edition: protocol.EditionJava,
+			Analyze: func(ip string, port int, _ time.Duration) (*protocol.ServerDetail, error) {
+				return &protocol.ServerDetail{IP: ip, Port: port, Software: "by ip"}, nil
+			},
+			AnalyzeHost: func(ip string, port int, hostname string, _ time.Duration) (*protocol.ServerDetail, error) {
+				gotHost = hostname
+				return &protocol.ServerDetail{IP: ip, Port: port, Software: "by host"}, nil
+			},
+		},
+		protocol.Analyzer{
+			Name: "bedrock", Edition: protocol.EditionBedrock,
+			Analyze: func(ip string, port int, _ time.Duration) (*protocol.ServerDetail, error) {
+				return &protocol.ServerDetail{IP: ip, Port: port}, nil
+			},
+		},
+	)
+
+	detail, err := reg.AnalyzeHost(protocol.EditionJava, "10.0.0.1", 25565, "play.example.net", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotHost != "play.example.net" || detail.Software != "by host" || detail.Hostname != "play.example.net" {
+		t.Errorf("host analyzer not used: host = %q, detail = %+v", gotHost, detail)
+	}
+
+	detail, err = reg.AnalyzeHost(protocol.EditionJava, "10.0.0.1", 25565, "", time.Second)
+	if err != nil || detail.Software != "by ip" || detail.Hostname != "" {
+		t.Errorf("without hostname: %+v, %v", detail, err)
+	}
+
+	// Analyzers without AnalyzeHost still report how the endpoint was reached
+	detail, err = reg.AnalyzeHost(protocol.EditionBedrock, "10.0.0.1", 19132, "play.example.net", time.Second)
+	if err != nil || detail.Hostname != "play.example.net" {
+		t.Errorf("bedrock: %+v, %v", detail, err)
+	}
+}
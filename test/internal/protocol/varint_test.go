@@ -19,6 +19,8 @@ func TestWriteVarInt(t *testing.T) {
 		{"128", 128, []byte{0x80, 0x01}, false},
 		{"255", 255, []byte{0xFF, 0x01}, false},
 		{"2097151", 2097151, []byte{0xFF, 0xFF, 0x7F}, false},
+		{"minus one", -1, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0x0F}, false},
+		{"min int32", -2147483648, []byte{0x80, 0x80, 0x80, 0x80, 0x08}, false},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,120 @@
+package scanner_test
+
+import (
+	"MinecraftCrawler/internal/scanner"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func collect(t *testing.T, d scanner.Discoverer) []scanner.Endpoint {
+	t.Helper()
+	out := make(chan scanner.Endpoint)
+	errc := make(chan error, 1)
+	go func() { errc <- d.Discover(context.Background(), out) }()
+
+	var eps []scanner.Endpoint
+	for ep := range out {
+		eps = append(eps, ep)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	return eps
+}
+
+func TestConnectScannerFindsOpenPort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+
+	// Un segundo puerto cerrado: reservamos uno y lo liberamos
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	openPort := ln.Addr().(*net.TCPAddr).Port
+	eps := collect(t, &scanner.ConnectScanner{
+		Targets:     []string{"127.0.0.1/32"},
+		Ports:       []int{openPort, closedPort},
+		Concurrency: 4,
+		Rate:        1000,
+		Timeout:     time.Second,
+	})
+
+	if len(eps) != 1 || eps[0] != (scanner.Endpoint{IP: "127.0.0.1", Port: openPort}) {
+		t.Errorf("endpoints = %v; want only 127.0.0.1:%d", eps, openPort)
+	}
+}
+
+func TestConnectScannerExclude(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	eps := collect(t, &scanner.ConnectScanner{
+		Targets: []string{"127.0.0.1"},
+		Ports:   []int{ln.Addr().(*net.TCPAddr).Port},
+		Exclude: []string{"127.0.0.0/8"},
+	})
+	if len(eps) != 0 {
+		t.Errorf("excluded host probed: %v", eps)
+	}
+}
+
+func TestConnectScannerInvalidTarget(t *testing.T) {
+	out := make(chan scanner.Endpoint)
+	s := &scanner.ConnectScanner{Targets: []string{"not-an-ip"}, Ports: []int{25565}}
+	if err := s.Discover(context.Background(), out); err == nil {
+		t.Error("expected error for invalid target")
+	}
+	if _, open := <-out; open {
+		t.Error("out not closed")
+	}
+}
+
+func TestConnectScannerNoTargets(t *testing.T) {
+	for _, targets := range [][]string{nil, {""}, {" ", ""}} {
+		out := make(chan scanner.Endpoint)
+		s := &scanner.ConnectScanner{Targets: targets, Ports: []int{25565}}
+		if err := s.Discover(context.Background(), out); err == nil {
+			t.Errorf("Discover(%q) succeeded without targets", targets)
+		}
+		if _, open := <-out; open {
+			t.Error("out not closed")
+		}
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	prefixes, err := scanner.ParsePrefixes([]string{"10.0.0.7/24", "192.168.1.1", " ", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"10.0.0.0/24", "192.168.1.1/32", "::1/128"}
+	if len(prefixes) != len(want) {
+		t.Fatalf("got %v; want %v", prefixes, want)
+	}
+	for i, p := range prefixes {
+		if p.String() != want[i] {
+			t.Errorf("prefix %d = %s; want %s", i, p, want[i])
+		}
+	}
+}
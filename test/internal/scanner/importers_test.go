@@ -0,0 +1,100 @@
+package scanner_test
+
+import (
+	"MinecraftCrawler/internal/scanner"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const masscanJSON = `[
+{   "ip": "192.0.2.1",   "timestamp": "1700000000", "ports": [ {"port": 25565, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 52} ] }
+,
+{   "ip": "192.0.2.2",   "timestamp": "1700000001", "ports": [ {"port": 25566, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 52} ] }
+]
+`
+
+const masscanList = `#masscan
+open tcp 25565 192.0.2.1 1700000000
+banner tcp 25565 192.0.2.1 1700000000 http HTTP/1.0 200 OK
+open tcp 25566 192.0.2.2 1700000001
+# end
+`
+
+const masscanXML = `<?xml version="1.0"?>
+<!-- masscan v1.3 scan -->
+<nmaprun scanner="masscan" start="1700000000" version="1.0-BETA"  xmloutputversion="1.03">
+<scaninfo type="syn" protocol="tcp" />
+<host endtime="1700000000"><address addr="192.0.2.1" addrtype="ipv4"/><ports><port protocol="tcp" portid="25565"><state state="open" reason="syn-ack" reason_ttl="52"/></port></ports></host>
+<host endtime="1700000001"><address addr="192.0.2.2" addrtype="ipv4"/><ports><port protocol="tcp" portid="25566"><state state="open" reason="syn-ack" reason_ttl="52"/></port></ports></host>
+<runstats><finished time="1700000002" timestr="2023-11-14 22:13:22" elapsed="2" /></runstats>
+</nmaprun>
+`
+
+const nmapXML = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE nmaprun>
+<nmaprun scanner="nmap" args="nmap -p 25565,25566 -oX - 192.0.2.0/30">
+<host starttime="1700000000"><status state="up"/>
+<address addr="192.0.2.1" addrtype="ipv4"/><address addr="00:11:22:33:44:55" addrtype="mac"/>
+<ports>
+<port protocol="tcp" portid="25565"><state state="open" reason="syn-ack"/><service name="minecraft"/></port>
+<port protocol="tcp" portid="25566"><state state="closed" reason="reset"/></port>
+</ports></host>
+<host starttime="1700000000"><status state="up"/>
+<address addr="192.0.2.2" addrtype="ipv4"/>
+<ports><port protocol="tcp" portid="25566"><state state="open" reason="syn-ack"/></port></ports></host>
+<host><status state="down"/><address addr="192.0.2.3" addrtype="ipv4"/></host>
+</nmaprun>
+`
+
+const zmapCSV = `saddr,sport,classification,success
+192.0.2.1,25565,synack,1
+192.0.2.9,25565,rst,0
+192.0.2.2,25566,synack,1
+`
+
+func TestImporters(t *testing.T) {
+	want := []scanner.Endpoint{{IP: "192.0.2.1", Port: 25565}, {IP: "192.0.2.2", Port: 25566}}
+
+	tests := []struct {
+		format string
+		input  string
+	}{
+		{scanner.FormatMasscanJSON, masscanJSON},
+		{scanner.FormatMasscanList, masscanList},
+		{scanner.FormatMasscanXML, masscanXML},
+		{scanner.FormatNmapXML, nmapXML},
+		{scanner.FormatZmapCSV, zmapCSV},
+		{scanner.FormatList, "192.0.2.1\n192.0.2.2:25566\n"},
+	}
+	for _, tt := range tests {
+		for _, format := range []string{tt.format, scanner.FormatAuto} {
+			t.Run(tt.format+"/"+format, func(t *testing.T) {
+				d, err := scanner.NewTargetSource(format, strings.NewReader(tt.input), []int{25565})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := collect(t, d); !reflect.DeepEqual(got, want) {
+					t.Errorf("endpoints = %v; want %v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestZmapCSVWithoutHeader(t *testing.T) {
+	d, err := scanner.NewTargetSource(scanner.FormatZmapCSV, strings.NewReader("192.0.2.1\n192.0.2.2\n"), []int{25570})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []scanner.Endpoint{{IP: "192.0.2.1", Port: 25570}, {IP: "192.0.2.2", Port: 25570}}
+	if got := collect(t, d); !reflect.DeepEqual(got, want) {
+		t.Errorf("endpoints = %v; want %v", got, want)
+	}
+}
+
+func TestUnknownTargetFormat(t *testing.T) {
+	if _, err := scanner.NewTargetSource("gnmap", strings.NewReader(""), []int{25565}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
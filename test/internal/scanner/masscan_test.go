@@ -68,3 +68,46 @@ func TestBuildArguments(t *testing.T) {
 	}
 }
 
+
+func TestBuildUDPArguments(t *testing.T) {
+	got := scanner.BuildUDPArguments("10.0.0.0/8", "500", []int{19132, 19133, 19134}, "", "payloads.txt")
+	want := []string{
+		"10.0.0.0/8",
+		"-p", "U:19132-19134",
+		"--rate", "500",
+		"-oJ", "-",
+		"--nmap-payloads", "payloads.txt",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildUDPArguments() = %v, want %v", got, want)
+	}
+}
+
+func TestBuildPortsArguments(t *testing.T) {
+	got := scanner.BuildPortsArguments("10.0.0.0/8", "500", []int{25575, 25565, 25566, 25567}, "")
+	want := []string{
+		"10.0.0.0/8",
+		"-p", "25565-25567,25575",
+		"--rate", "500",
+		"-oJ", "-",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildPortsArguments() = %v, want %v", got, want)
+	}
+}
+
+func TestParseMasscanLine(t *testing.T) {
+	res, ok := scanner.ParseMasscanLine([]byte(`{   "ip": "10.0.0.1",   "timestamp": "1700000000", "ports": [ {"port": 25565, "proto": "tcp", "status": "open"} ] },`))
+	if !ok {
+		t.Fatal("valid line rejected")
+	}
+	if res.IP != "10.0.0.1" || len(res.Ports) != 1 || res.Ports[0].Port != 25565 || res.Ports[0].Proto != "tcp" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	for _, line := range []string{"[", "]", "", `{"finished": 1}`, "garbage that is long enough"} {
+		if _, ok := scanner.ParseMasscanLine([]byte(line)); ok {
+			t.Errorf("line %q accepted", line)
+		}
+	}
+}
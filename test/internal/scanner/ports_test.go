@@ -0,0 +1,43 @@
+package scanner_test
+
+import (
+	"MinecraftCrawler/internal/scanner"
+	"reflect"
+	"testing"
+)
+
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []int
+		ok   bool
+	}{
+		{"25565", []int{25565}, true},
+		{"25565-25568,19132", []int{25565, 25566, 25567, 25568, 19132}, true},
+		{"25565, 25565-25566 ,", []int{25565, 25566}, true},
+		{"25570-25565", nil, false},
+		{"0", nil, false},
+		{"65536", nil, false},
+		{"abc", nil, false},
+		{"", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := scanner.ParsePorts(tt.spec)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParsePorts(%q) error = %v; want ok=%t", tt.spec, err, tt.ok)
+			continue
+		}
+		if tt.ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePorts(%q) = %v; want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestFormatPorts(t *testing.T) {
+	if got := scanner.FormatPorts([]int{19132, 25567, 25565, 25566}, ""); got != "19132,25565-25567" {
+		t.Errorf("FormatPorts = %s", got)
+	}
+	if got := scanner.FormatPorts([]int{19132, 19133}, "U:"); got != "U:19132-19133" {
+		t.Errorf("FormatPorts UDP = %s", got)
+	}
+}
@@ -0,0 +1,53 @@
+package scanner_test
+
+import (
+	"MinecraftCrawler/internal/mctest"
+	"MinecraftCrawler/internal/protocol"
+	"MinecraftCrawler/internal/scanner"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestResolveHostHandshakeUsesSRVTarget follows a domain through its SRV
+// record and checks that the probe announces the SRV target, as the vanilla
+// client does, rather than the name that was queried.
+func TestResolveHostHandshakeUsesSRVTarget(t *testing.T) {
+	var status, routed protocol.StatusResponse
+	status.Version.Name, status.Version.Protocol, status.Description = "Velocity 3.3.0", 765, "Fallback"
+	routed.Version.Name, routed.Version.Protocol, routed.Description = "Velocity 3.3.0", 765, "Routed"
+	srv, err := mctest.Start(mctest.Config{
+		Status:     status,
+		HostStatus: map[string]interface{}{"node.example.net": routed},
+	})
+	if err != nil {
+		t.Fatalf("mctest.Start failed: %v", err)
+	}
+	t.Cleanup(srv.Close)
+
+	dns := fakeResolver{
+		hosts: map[string][]string{"node.example.net": {srv.Host}},
+		srv:   map[string][]*net.SRV{"play.example.net": {{Target: "node.example.net.", Port: uint16(srv.Port)}}},
+	}
+	eps, err := scanner.ResolveHost(context.Background(), dns, "play.example.net", 0, []int{25565})
+	if err != nil {
+		t.Fatalf("ResolveHost failed: %v", err)
+	}
+	if len(eps) != 1 || eps[0].Hostname != "node.example.net" {
+		t.Fatalf("endpoints = %+v, want one with hostname node.example.net", eps)
+	}
+
+	ep := eps[0]
+	detail, err := protocol.AnalyzeServerHost(ep.IP, ep.Port, ep.Hostname, 2*time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeServerHost failed: %v", err)
+	}
+	if detail.MOTD != "Routed" || detail.Hostname != "node.example.net" {
+		t.Errorf("motd = %q, hostname = %q", detail.MOTD, detail.Hostname)
+	}
+	hs := srv.Handshakes()
+	if len(hs) == 0 || hs[0].Host != "node.example.net" {
+		t.Errorf("status handshake = %+v, want host node.example.net", hs)
+	}
+}
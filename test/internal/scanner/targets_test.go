@@ -0,0 +1,219 @@
+package scanner_test
+
+import (
+	"MinecraftCrawler/internal/scanner"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeResolver answers from fixed tables instead of the network.
+type fakeResolver struct {
+	hosts map[string][]string
+	srv   map[string][]*net.SRV
+}
+
+var testDNS = fakeResolver{
+	hosts: map[string][]string{
+		"mc.example.com":    {"203.0.113.10", "2001:db8::10"},
+		"node1.example.net": {"198.51.100.1"},
+		"node2.example.net": {"198.51.100.2", "::ffff:198.51.100.1"},
+		"play.example.net":  {"192.0.2.7"},
+	},
+	srv: map[string][]*net.SRV{
+		"play.example.net": {
+			{Target: "node1.example.net.", Port: 25570, Priority: 0},
+			{Target: "node2.example.net.", Port: 25570, Priority: 10},
+		},
+		"gone.example.net":   {{Target: "."}},
+		"broken.example.net": {{Target: "missing.example.net.", Port: 25565}},
+	},
+}
+
+func (f fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addrs, ok := f.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (f fakeResolver) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if service != "minecraft" || proto != "tcp" {
+		return "", nil, errors.New("unexpected service")
+	}
+	if records, ok := f.srv[name]; ok {
+		return "_minecraft._tcp." + name + ".", records, nil
+	}
+	return "", nil, errors.New("no such host")
+}
+
+func (f fakeResolver) LookupAddr(context.Context, string) ([]string, error) {
+	return nil, errors.New("no PTR")
+}
+
+func TestTargetList(t *testing.T) {
+	input := `# servidores conocidos
+1.2.3.4
+5.6.7.8:25570
+
+[2001:db8::1]:25565
+bad line
+9.9.9.9:99999
+10.0.0.1 # comentario
+192.168.0.0/31
+192.168.1.0/31:25570
+mc.example.com:25566
+missing.example.com
+`
+	eps := collect(t, &scanner.TargetList{Reader: strings.NewReader(input), Ports: []int{25565}, Resolver: testDNS})
+
+	want := []scanner.Endpoint{
+		{IP: "1.2.3.4", Port: 25565},
+		{IP: "5.6.7.8", Port: 25570},
+		{IP: "2001:db8::1", Port: 25565},
+		{IP: "10.0.0.1", Port: 25565},
+		{IP: "192.168.0.0", Port: 25565},
+		{IP: "192.168.0.1", Port: 25565},
+		{IP: "192.168.1.0", Port: 25570},
+		{IP: "192.168.1.1", Port: 25570},
+		{IP: "203.0.113.10", Port: 25566, Hostname: "mc.example.com"},
+		{IP: "2001:db8::10", Port: 25566, Hostname: "mc.example.com"},
+	}
+	if len(eps) != len(want) {
+		t.Fatalf("endpoints = %v; want %v", eps, want)
+	}
+	for i := range want {
+		if eps[i] != want[i] {
+			t.Errorf("endpoint %d = %v; want %v", i, eps[i], want[i])
+		}
+	}
+}
+
+func TestResolveHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		port    int
+		want    []scanner.Endpoint
+		wantErr bool
+	}{
+		{
+			name: "SRV",
+			host: "play.example.net.",
+			want: []scanner.Endpoint{
+				{IP: "198.51.100.1", Port: 25570, Hostname: "node1.example.net"},
+				{IP: "198.51.100.2", Port: 25570, Hostname: "node2.example.net"},
+			},
+		},
+		{
+			name: "ExplicitPortSkipsSRV",
+			host: "play.example.net",
+			port: 25565,
+			want: []scanner.Endpoint{{IP: "192.0.2.7", Port: 25565, Hostname: "play.example.net"}},
+		},
+		{
+			name: "NoSRVUsesDefaultPorts",
+			host: "mc.example.com",
+			want: []scanner.Endpoint{
+				{IP: "203.0.113.10", Port: 25565, Hostname: "mc.example.com"},
+				{IP: "203.0.113.10", Port: 25575, Hostname: "mc.example.com"},
+				{IP: "2001:db8::10", Port: 25565, Hostname: "mc.example.com"},
+				{IP: "2001:db8::10", Port: 25575, Hostname: "mc.example.com"},
+			},
+		},
+		{name: "ServiceNotAvailable", host: "gone.example.net", wantErr: true},
+		{name: "BrokenTarget", host: "broken.example.net", wantErr: true},
+		{name: "Unknown", host: "missing.example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scanner.ResolveHost(context.Background(), testDNS, tt.host, tt.port, []int{25565, 25575})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveHost error = %v; wantErr %t", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveHost = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetListSRV(t *testing.T) {
+	eps := collect(t, &scanner.TargetList{
+		Reader:   strings.NewReader("play.example.net\nplay.example.net:25565\n"),
+		Ports:    []int{25565},
+		Resolver: testDNS,
+	})
+	want := []scanner.Endpoint{
+		{IP: "198.51.100.1", Port: 25570, Hostname: "node1.example.net"},
+		{IP: "198.51.100.2", Port: 25570, Hostname: "node2.example.net"},
+		{IP: "192.0.2.7", Port: 25565, Hostname: "play.example.net"},
+	}
+	if !reflect.DeepEqual(eps, want) {
+		t.Errorf("endpoints = %v; want %v", eps, want)
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		in     string
+		prefix string
+		host   string
+		port   int
+		ok     bool
+	}{
+		{"1.2.3.4", "1.2.3.4/32", "", 0, true},
+		{"2001:db8::/126", "2001:db8::/126", "", 0, true},
+		{"[2001:db8::]/126", "", "", 0, false},
+		{"[2001:db8::/126]:19132", "2001:db8::/126", "", 19132, true},
+		{"play.example.net.", "", "play.example.net", 0, true},
+		{"play.example.net:0", "", "", 0, false},
+		{"-bad-.example", "", "", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := scanner.ParseTarget(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseTarget(%q) error = %v; want ok=%t", tt.in, err, tt.ok)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		prefix := ""
+		if got.Prefix.IsValid() {
+			prefix = got.Prefix.String()
+		}
+		if prefix != tt.prefix || got.Host != tt.host || got.Port != tt.port {
+			t.Errorf("ParseTarget(%q) = %+v", tt.in, got)
+		}
+	}
+}
+
+func TestTargetListMultiplePorts(t *testing.T) {
+	eps := collect(t, &scanner.TargetList{
+		Reader: strings.NewReader("1.2.3.4\n5.6.7.8:25570\n"),
+		Ports:  []int{25565, 19132},
+	})
+	want := []scanner.Endpoint{
+		{IP: "1.2.3.4", Port: 25565},
+		{IP: "1.2.3.4", Port: 19132},
+		{IP: "5.6.7.8", Port: 25570},
+	}
+	if len(eps) != len(want) {
+		t.Fatalf("endpoints = %v; want %v", eps, want)
+	}
+	for i := range want {
+		if eps[i] != want[i] {
+			t.Errorf("endpoint %d = %v; want %v", i, eps[i], want[i])
+		}
+	}
+}
+
+func TestEndpointString(t *testing.T) {
+	if got := (scanner.Endpoint{IP: "::1", Port: 25565}).String(); got != "[::1]:25565" {
+		t.Errorf("String() = %s", got)
+	}
+}
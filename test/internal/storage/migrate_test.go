@@ -0,0 +1,138 @@
+package storage_test
+
+import (
+	"MinecraftCrawler/internal/storage"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// baselineSchema is the servers table created by releases that predate
+// schema_version.
+const baselineSchema = `
+	CREATE TABLE servers (
+		ip TEXT,
+		port INTEGER,
+		version_name TEXT,
+		protocol INTEGER,
+		players_online INTEGER,
+		players_max INTEGER,
+		whitelist BOOLEAN,
+		software TEXT,
+		mods TEXT,
+		plugins TEXT,
+		secure_chat BOOLEAN,
+		timestamp DATETIME,
+		UNIQUE(ip, port)
+	);
+	INSERT INTO servers (ip, port, version_name, timestamp) VALUES ('10.0.0.1', 25565, '1.8.9', '2023-05-01 10:00:00');`
+
+func TestMigrate_UpgradesBaselineDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := storage.OpenDatabase(path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(baselineSchema); err != nil {
+		t.Fatalf("failed to create baseline schema: %v", err)
+	}
+
+	applied, err := storage.Migrate(db)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	latest, _ := storage.LatestVersion()
+	if applied != latest {
+		t.Errorf("Expected %d migrations applied, got %d", latest, applied)
+	}
+
+	version, err := storage.SchemaVersion(db)
+	if err != nil {
+		t.Fatalf("SchemaVersion failed: %v", err)
+	}
+	if version != latest {
+		t.Errorf("Expected schema version %d, got %d", latest, version)
+	}
+
+	var edition, firstSeen string
+	err = db.QueryRow("SELECT edition, first_seen FROM servers WHERE ip = '10.0.0.1'").Scan(&edition, &firstSeen)
+	if err != nil {
+		t.Fatalf("Existing row not readable after migration: %v", err)
+	}
+	if edition != "java" {
+		t.Errorf("Expected edition backfilled to java, got %q", edition)
+	}
+	if firstSeen == "" {
+		t.Error("Expected first_seen backfilled from timestamp")
+	}
+
+	applied, err = storage.Migrate(db)
+	if err != nil || applied != 0 {
+		t.Errorf("Second Migrate() = %d, %v; want 0, nil", applied, err)
+	}
+}
+
+func TestSchemaVersion_DoesNotCreateTable(t *testing.T) {
+	db, err := storage.OpenDatabase(filepath.Join(t.TempDir(), "empty.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	version, err := storage.SchemaVersion(db)
+	if err != nil || version != 0 {
+		t.Fatalf("SchemaVersion() = %d, %v; want 0, nil", version, err)
+	}
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Errorf("SchemaVersion created %d tables in an unmigrated database", tables)
+	}
+}
+
+func TestMigrate_ReportsUnexpectedColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "partial.db")
+	db, err := storage.OpenDatabase(path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// The baseline never had motd, so a column that 0002 adds must not be
+	// silently accepted: it means the schema is not what schema_version says.
+	if _, err := db.Exec(baselineSchema + `ALTER TABLE servers ADD COLUMN motd TEXT;`); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+
+	applied, err := storage.Migrate(db)
+	if err == nil || !strings.Contains(err.Error(), "0002_motd") {
+		t.Fatalf("Migrate() error = %v, want a failure in 0002_motd", err)
+	}
+	if applied != 1 {
+		t.Errorf("applied = %d, want only 0001 before the failure", applied)
+	}
+	if version, _ := storage.SchemaVersion(db); version != 1 {
+		t.Errorf("schema version = %d, want 1", version)
+	}
+	if _, err := db.Exec("SELECT motd_json FROM servers"); err == nil {
+		t.Error("failed migration 0002 was partially applied")
+	}
+}
+
+func TestMigrations_AreOrdered(t *testing.T) {
+	migrations, err := storage.Migrations()
+	if err != nil {
+		t.Fatalf("Migrations failed: %v", err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d; versions must be contiguous", i, m.Version)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package storage_test
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"MinecraftCrawler/internal/storage"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPlayerSightings(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "players.db"))
+	ctx := context.Background()
+	t1 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	notch := protocol.PlayerSample{Name: "Notch", ID: "069a79f4-44e9-4726-a5be-fca90e38aaf5"}
+	batch := []*protocol.ServerDetail{
+		{IP: "10.5.0.1", Port: 25565, Timestamp: t1, PlayerSample: []protocol.PlayerSample{notch, {Name: "jeb_", ID: "853c80ef-3c37-49fd-aa49-938b674adae6"}}},
+		{IP: "10.5.0.2", Port: 25565, Timestamp: t1, QueryPlayers: []string{"notch"}},
+	}
+	if err := store.WriteBatch(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.WriteBatch(ctx, []*protocol.ServerDetail{
+		{IP: "10.5.0.1", Port: 25565, Timestamp: t2, PlayerSample: []protocol.PlayerSample{notch}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	collect := func(filter storage.SightingFilter) []storage.Sighting {
+		var got []storage.Sighting
+		err := store.QuerySightings(ctx, filter, func(s *storage.Sighting) error {
+			got = append(got, *s)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("QuerySightings failed: %v", err)
+		}
+		return got
+	}
+
+	// Sin guiones y en mayúsculas también debe encontrarse
+	byUUID := collect(storage.SightingFilter{UUID: "069A79F444E94726A5BEFCA90E38AAF5"})
+	if len(byUUID) != 2 || byUUID[0].IP != "10.5.0.1" || !byUUID[0].Timestamp.Equal(t2) || !byUUID[1].Timestamp.Equal(t1) {
+		t.Errorf("by uuid = %+v", byUUID)
+	}
+	if byUUID[0].Source != storage.SightingSLP || byUUID[0].Name != "Notch" {
+		t.Errorf("sighting = %+v", byUUID[0])
+	}
+
+	byName := collect(storage.SightingFilter{Name: "NOTCH"})
+	if len(byName) != 3 {
+		t.Fatalf("by name = %+v, want 3 sightings", byName)
+	}
+	query := 0
+	for _, s := range byName {
+		if s.Source == storage.SightingQuery {
+			query++
+			if s.IP != "10.5.0.2" || s.UUID != "" {
+				t.Errorf("query sighting = %+v", s)
+			}
+		}
+	}
+	if query != 1 {
+		t.Errorf("query sightings = %d, want 1", query)
+	}
+
+	// El límite se queda con los avistamientos más recientes
+	latest := collect(storage.SightingFilter{Name: "notch", Limit: 1})
+	if len(latest) != 1 || !latest[0].Timestamp.Equal(t2) {
+		t.Errorf("limit 1 = %+v, want the sighting at %v", latest, t2)
+	}
+
+	if got := collect(storage.SightingFilter{Name: "Dinnerbone"}); len(got) != 0 {
+		t.Errorf("unknown player = %+v", got)
+	}
+}
+
+func TestNormalizeUUID(t *testing.T) {
+	tests := map[string]string{
+		"069a79f444e94726a5befca90e38aaf5":     "069a79f4-44e9-4726-a5be-fca90e38aaf5",
+		"069A79F4-44E9-4726-A5BE-FCA90E38AAF5": "069a79f4-44e9-4726-a5be-fca90e38aaf5",
+		"Notch":                                "notch",
+	}
+	for in, want := range tests {
+		if got := storage.NormalizeUUID(in); got != want {
+			t.Errorf("NormalizeUUID(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if storage.IsUUID("Notch") || !storage.IsUUID("069a79f444e94726a5befca90e38aaf5") {
+		t.Error("IsUUID misclassified")
+	}
+}
@@ -94,3 +94,95 @@ func TestStartSQLiteManager_InMemory(t *testing.T) {
 	}
 }
 
+
+func TestFlush_DeduplicatesIcons(t *testing.T) {
+	db, err := storage.NewDatabase(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create in-memory database: %v", err)
+	}
+	defer db.Close()
+
+	icon := []byte{0x89, 0x50, 0x4E, 0x47}
+	batch := []*protocol.ServerDetail{
+		{IP: "10.0.0.1", Port: 25565, Icon: icon, IconHash: "abc123", Mods: map[string]string{}},
+		{IP: "10.0.0.2", Port: 25565, Icon: icon, IconHash: "abc123", Mods: map[string]string{}},
+		{IP: "10.0.0.3", Port: 25565, Mods: map[string]string{}},
+	}
+	if err := storage.Flush(db, batch); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	var icons int
+	if err := db.QueryRow("SELECT COUNT(*) FROM icons").Scan(&icons); err != nil {
+		t.Fatalf("Failed to count icons: %v", err)
+	}
+	if icons != 1 {
+		t.Errorf("Expected 1 icon row, got %d", icons)
+	}
+
+	var linked int
+	if err := db.QueryRow("SELECT COUNT(*) FROM servers WHERE icon_hash = 'abc123'").Scan(&linked); err != nil {
+		t.Fatalf("Failed to count servers: %v", err)
+	}
+	if linked != 2 {
+		t.Errorf("Expected 2 servers referencing the icon, got %d", linked)
+	}
+
+	var data []byte
+	if err := db.QueryRow("SELECT data FROM icons WHERE hash = 'abc123'").Scan(&data); err != nil {
+		t.Fatalf("Failed to read icon: %v", err)
+	}
+	if string(data) != string(icon) {
+		t.Errorf("Stored icon = %v, want %v", data, icon)
+	}
+}
+
+func TestFlush_KeepsObservationHistory(t *testing.T) {
+	db, err := storage.NewDatabase(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create in-memory database: %v", err)
+	}
+	defer db.Close()
+
+	first := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+
+	scans := []*protocol.ServerDetail{
+		{IP: "10.0.0.1", Port: 25565, VersionName: "1.20.1", PlayersOnline: 3, MOTD: "Hello", Timestamp: first},
+		{IP: "10.0.0.1", Port: 25565, VersionName: "1.20.4", PlayersOnline: 7, MOTD: "Hello", Timestamp: second},
+	}
+	for _, s := range scans {
+		if err := storage.Flush(db, []*protocol.ServerDetail{s}); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+	}
+
+	var servers, observations int
+	_ = db.QueryRow("SELECT COUNT(*) FROM servers").Scan(&servers)
+	_ = db.QueryRow("SELECT COUNT(*) FROM observations").Scan(&observations)
+	if servers != 1 {
+		t.Errorf("Expected 1 server row, got %d", servers)
+	}
+	if observations != 2 {
+		t.Errorf("Expected 2 observations, got %d", observations)
+	}
+
+	var version string
+	var firstSeen, lastSeen time.Time
+	err = db.QueryRow("SELECT version_name, first_seen, last_seen FROM servers").Scan(&version, &firstSeen, &lastSeen)
+	if err != nil {
+		t.Fatalf("Failed to query server: %v", err)
+	}
+	if version != "1.20.4" {
+		t.Errorf("Expected latest version 1.20.4, got %s", version)
+	}
+	if !firstSeen.Equal(first) || !lastSeen.Equal(second) {
+		t.Errorf("first_seen/last_seen = %v/%v, want %v/%v", firstSeen, lastSeen, first, second)
+	}
+
+	var hashes int
+	_ = db.QueryRow("SELECT COUNT(DISTINCT motd_hash) FROM observations").Scan(&hashes)
+	if hashes != 1 {
+		t.Errorf("Expected one distinct MOTD hash, got %d", hashes)
+	}
+}
@@ -0,0 +1,369 @@
+package storage_test
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"MinecraftCrawler/internal/storage"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func openTestStore(t *testing.T, dsn string) storage.Store {
+	t.Helper()
+	store, err := storage.Open(dsn)
+	if err != nil {
+		t.Fatalf("Open(%q) failed: %v", dsn, err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if _, err := store.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	return store
+}
+
+func testStoreRoundTrip(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	ts := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	batch := []*protocol.ServerDetail{
+		{
+			IP: "10.1.0.1", Port: 25565, Edition: protocol.EditionJava, VersionName: "1.20.4",
+			PlayersOnline: 4, PlayersMax: 20, MOTD: "Hello", IsWhitelist: true, LoginOutcome: protocol.LoginWhitelisted,
+			KickReason: "No estás en la lista blanca", KickCategory: protocol.KickWhitelist,
+			Mods: map[string]string{"forge": "47.2.0"}, Plugins: []string{"WorldEdit"}, Timestamp: ts,
+			Software: "Forge", SoftwareConfidence: 0.95, ModLoader: protocol.ModLoaderForge, FMLNetworkVersion: 3, ModsTruncated: true,
+			ModChannels: []protocol.ForgeChannel{{Name: "create:main", Version: "3", Required: true}},
+			QueryKV: map[string]string{"gametype": "SMP", "hostport": "25565"}, QueryPlayers: []string{"Notch", "jeb_"},
+		},
+		{IP: "10.1.0.2", Port: 19132, Edition: protocol.EditionBedrock, Mods: map[string]string{}, Timestamp: ts},
+		{IP: "10.1.0.1", Port: 25575, Edition: protocol.EditionJava, Software: "RCON Service", RconOpen: true, Timestamp: ts},
+	}
+	if err := store.WriteBatch(ctx, batch); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+
+	var got []*storage.ServerRecord
+	err := store.QueryServers(ctx, storage.Filter{IP: "10.1.0.1", Port: 25565}, func(r *storage.ServerRecord) error {
+		got = append(got, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("QueryServers failed: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(got))
+	}
+
+	r := got[0]
+	if r.VersionName != "1.20.4" || r.PlayersOnline != 4 || !r.IsWhitelist || r.LoginOutcome != protocol.LoginWhitelisted {
+		t.Errorf("unexpected record: %+v", r)
+	}
+	if r.Mods["forge"] != "47.2.0" || len(r.Plugins) != 1 || r.Plugins[0] != "WorldEdit" {
+		t.Errorf("mods/plugins not decoded: %v %v", r.Mods, r.Plugins)
+	}
+	if r.Software != "Forge" || r.SoftwareConfidence != 0.95 {
+		t.Errorf("software = %q (%v)", r.Software, r.SoftwareConfidence)
+	}
+	if r.ModLoader != protocol.ModLoaderForge || r.FMLNetworkVersion != 3 || !r.ModsTruncated ||
+		len(r.ModChannels) != 1 || r.ModChannels[0].Name != "create:main" || !r.ModChannels[0].Required {
+		t.Errorf("forge data not stored: %q %d %v %+v", r.ModLoader, r.FMLNetworkVersion, r.ModsTruncated, r.ModChannels)
+	}
+	if !r.FirstSeen.Equal(ts) || !r.LastSeen.Equal(ts) {
+		t.Errorf("first/last seen = %v/%v, want %v", r.FirstSeen, r.LastSeen, ts)
+	}
+	if r.KickReason != "No estás en la lista blanca" || r.KickCategory != protocol.KickWhitelist {
+		t.Errorf("kick = %q (%q)", r.KickReason, r.KickCategory)
+	}
+	if r.RconOpen {
+		t.Error("rcon_open set on the game port")
+	}
+	if r.QueryKV["gametype"] != "SMP" || len(r.QueryPlayers) != 2 || r.QueryPlayers[1] != "jeb_" {
+		t.Errorf("query data not stored: %v %v", r.QueryKV, r.QueryPlayers)
+	}
+
+	rcon := false
+	_ = store.QueryServers(ctx, storage.Filter{IP: "10.1.0.1", Port: 25575}, func(r *storage.ServerRecord) error {
+		rcon = r.RconOpen
+		return nil
+	})
+	if !rcon {
+		t.Error("rcon_open not persisted")
+	}
+
+	count := 0
+	_ = store.QueryServers(ctx, storage.Filter{}, func(*storage.ServerRecord) error {
+		count++
+		return nil
+	})
+	if count != 3 {
+		t.Errorf("Expected 3 records without filter, got %d", count)
+	}
+
+	// The same server twice in one batch: both backends keep the newest status
+	// and the first sighting as first_seen.
+	later := ts.Add(time.Hour)
+	err = store.WriteBatch(ctx, []*protocol.ServerDetail{
+		{IP: "10.1.0.3", Port: 25565, Edition: protocol.EditionJava, VersionName: "1.20", Timestamp: ts},
+		{IP: "10.1.0.3", Port: 25565, Edition: protocol.EditionJava, VersionName: "1.21", Timestamp: later},
+	})
+	if err != nil {
+		t.Fatalf("WriteBatch with a duplicate failed: %v", err)
+	}
+	_ = store.QueryServers(ctx, storage.Filter{IP: "10.1.0.3"}, func(r *storage.ServerRecord) error {
+		if r.VersionName != "1.21" || !r.FirstSeen.Equal(ts) || !r.LastSeen.Equal(later) {
+			t.Errorf("duplicate merged to %q, first/last seen %v/%v; want 1.21, %v/%v",
+				r.VersionName, r.FirstSeen, r.LastSeen, ts, later)
+		}
+		return nil
+	})
+}
+
+func TestSQLiteStore(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "store.db"))
+	if _, ok := store.(*storage.SQLiteStore); !ok {
+		t.Fatalf("Open returned %T for a file path, want *storage.SQLiteStore", store)
+	}
+
+	current, latest, err := store.SchemaVersion(context.Background())
+	if err != nil || current != latest {
+		t.Errorf("SchemaVersion() = %d, %d, %v; want current == latest", current, latest, err)
+	}
+	testStoreRoundTrip(t, store)
+}
+
+// TestPostgresStore runs against a real server when MCCRAWLER_TEST_POSTGRES_DSN
+// points to a disposable database.
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("MCCRAWLER_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("MCCRAWLER_TEST_POSTGRES_DSN not set")
+	}
+	store := openTestStore(t, dsn)
+	if _, ok := store.(*storage.PostgresStore); !ok {
+		t.Fatalf("Open returned %T for a postgres DSN, want *storage.PostgresStore", store)
+	}
+	testStoreRoundTrip(t, store)
+}
+
+// TestPostgresConcurrentMigrate starts several scanners against one empty
+// schema at once: every migration must be applied exactly once and none of
+// them may fail on schema_version.
+func TestPostgresConcurrentMigrate(t *testing.T) {
+	dsn := os.Getenv("MCCRAWLER_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("MCCRAWLER_TEST_POSTGRES_DSN not set")
+	}
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	schema := fmt.Sprintf("mccrawler_migrate_%d", time.Now().UnixNano())
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("CREATE SCHEMA failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = conn.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		conn.Close(ctx)
+	})
+
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	schemaDSN := dsn + sep + "search_path=" + schema
+
+	const scanners = 4
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for i := 0; i < scanners; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store, err := storage.Open(schemaDSN)
+			if err != nil {
+				t.Errorf("Open failed: %v", err)
+				return
+			}
+			defer store.Close()
+			applied, err := store.Migrate(ctx)
+			if err != nil {
+				t.Errorf("concurrent Migrate failed: %v", err)
+			}
+			mu.Lock()
+			total += applied
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	store := openTestStore(t, schemaDSN)
+	current, latest, err := store.SchemaVersion(ctx)
+	if err != nil {
+		t.Fatalf("SchemaVersion failed: %v", err)
+	}
+	if current != latest || total != latest {
+		t.Errorf("version %d/%d after %d migrations applied in total", current, latest, total)
+	}
+}
+
+func TestQueryServersFilters(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "filters.db"))
+	ctx := context.Background()
+	now := time.Now()
+
+	batch := []*protocol.ServerDetail{
+		{IP: "10.2.0.1", Port: 25565, VersionName: "Paper 1.20.4", PlayersOnline: 50, IsWhitelist: false, LoginOutcome: protocol.LoginOffline, Software: "Paper", Timestamp: now},
+		{IP: "10.2.0.2", Port: 25565, VersionName: "1.8.9", PlayersOnline: 2, IsWhitelist: true, LoginOutcome: protocol.LoginWhitelisted, Timestamp: now,
+			ProxyType: protocol.ProxyVelocity, ProtocolMin: 47, ProtocolMax: 769, HostRouting: true},
+		{IP: "10.2.0.3", Port: 25565, VersionName: "1.20.4", PlayersOnline: 10, IsWhitelist: true, Timestamp: now.Add(-72 * time.Hour)},
+	}
+	if err := store.WriteBatch(ctx, batch); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+
+	yes, no := true, false
+	tests := []struct {
+		name   string
+		filter storage.Filter
+		want   []string
+	}{
+		{"Version", storage.Filter{Version: "1.20.4"}, []string{"10.2.0.1", "10.2.0.3"}},
+		{"MinPlayers", storage.Filter{MinPlayers: 10}, []string{"10.2.0.1", "10.2.0.3"}},
+		{"Whitelist", storage.Filter{Whitelist: &yes}, []string{"10.2.0.2", "10.2.0.3"}},
+		{"Login", storage.Filter{Login: protocol.LoginOffline}, []string{"10.2.0.1"}},
+		{"Software", storage.Filter{Software: "paper"}, []string{"10.2.0.1"}},
+		{"Proxy", storage.Filter{Proxy: &yes}, []string{"10.2.0.2"}},
+		{"NotProxy", storage.Filter{Proxy: &no}, []string{"10.2.0.1", "10.2.0.3"}},
+		{"SeenSince", storage.Filter{SeenSince: now.Add(-24 * time.Hour)}, []string{"10.2.0.1", "10.2.0.2"}},
+		{"Combined", storage.Filter{Version: "1.20", Whitelist: &yes}, []string{"10.2.0.3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := store.QueryServers(ctx, tt.filter, func(r *storage.ServerRecord) error {
+				got = append(got, r.IP)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("QueryServers failed: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("QueryServers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartManager_FlushesOnCancel(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "manager.db"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resultChan := make(chan *protocol.ServerDetail, 5)
+	done := storage.StartManager(ctx, store, resultChan, 100)
+
+	resultChan <- &protocol.ServerDetail{IP: "10.3.0.1", Port: 25565, Timestamp: time.Now()}
+	cancel()
+
+	// The pending batch must reach the database before the channel is closed.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		count := 0
+		_ = store.QueryServers(context.Background(), storage.Filter{}, func(*storage.ServerRecord) error {
+			count++
+			return nil
+		})
+		if count == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pending batch not flushed after cancel")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Results produced by in-flight workers after the signal are still stored.
+	resultChan <- &protocol.ServerDetail{IP: "10.3.0.2", Port: 25565, Timestamp: time.Now()}
+	close(resultChan)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("StartManager did not signal completion")
+	}
+
+	count := 0
+	_ = store.QueryServers(context.Background(), storage.Filter{}, func(*storage.ServerRecord) error {
+		count++
+		return nil
+	})
+	if count != 2 {
+		t.Errorf("Expected 2 stored servers, got %d", count)
+	}
+}
+
+func TestManager_FlushesOnInterval(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "interval.db"))
+	manager := storage.NewManager(store, 500, 20*time.Millisecond)
+
+	resultChan := make(chan *protocol.ServerDetail, 5)
+	done := manager.Start(context.Background(), resultChan)
+	resultChan <- &protocol.ServerDetail{IP: "10.4.0.1", Port: 25565, Timestamp: time.Now()}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for manager.Stats().Written != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("result not flushed by interval; stats = %+v", manager.Stats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(resultChan)
+	<-done
+
+	stats := manager.Stats()
+	if stats.Batches != 1 || stats.FailedInserts != 0 || stats.FailedBatches != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.MaxLatency <= 0 || stats.AvgLatency() <= 0 {
+		t.Errorf("latency not recorded: %+v", stats)
+	}
+}
+
+func TestHostnameSurvivesIPProbe(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "hostname.db"))
+	ctx := context.Background()
+	ts := time.Date(2024, 7, 1, 8, 0, 0, 0, time.UTC)
+
+	for i, detail := range []*protocol.ServerDetail{
+		{IP: "10.7.0.1", Port: 25565, Hostname: "play.example.net", MOTD: "Via domain", Timestamp: ts},
+		{IP: "10.7.0.1", Port: 25565, MOTD: "Via IP", Timestamp: ts.Add(time.Hour)},
+	} {
+		if err := store.WriteBatch(ctx, []*protocol.ServerDetail{detail}); err != nil {
+			t.Fatalf("WriteBatch %d failed: %v", i, err)
+		}
+	}
+
+	var got []*storage.ServerRecord
+	err := store.QueryServers(ctx, storage.Filter{IP: "10.7.0.1"}, func(r *storage.ServerRecord) error {
+		got = append(got, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("QueryServers failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Hostname != "play.example.net" || got[0].MOTD != "Via IP" {
+		t.Errorf("records = %+v", got)
+	}
+}
@@ -0,0 +1,74 @@
+package storage_test
+
+import (
+	"MinecraftCrawler/internal/protocol"
+	"MinecraftCrawler/internal/storage"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVirtualHosts(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "vhosts.db"))
+	ctx := context.Background()
+	t1 := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	survival := protocol.VirtualHost{
+		Hostname: "Survival.example.net", Source: protocol.HostSourceList, VersionName: "Velocity 3.3.0",
+		Protocol: 765, MOTD: "Survival", PlayersOnline: 12, PlayersMax: 500,
+	}
+	batch := []*protocol.ServerDetail{
+		{IP: "10.6.0.1", Port: 25565, Timestamp: t1, VirtualHosts: []protocol.VirtualHost{
+			survival,
+			{Hostname: "creative.example.net", Source: protocol.HostSourceSRV, MOTD: "Creative", IconHash: "abc", Icon: []byte{1}},
+		}},
+		{IP: "10.6.0.2", Port: 25565, Timestamp: t1},
+	}
+	if err := store.WriteBatch(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+	survival.PlayersOnline = 30
+	if err := store.WriteBatch(ctx, []*protocol.ServerDetail{
+		{IP: "10.6.0.1", Port: 25565, Timestamp: t2, VirtualHosts: []protocol.VirtualHost{survival}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	collect := func(filter storage.VirtualHostFilter) []storage.VirtualHostRecord {
+		var got []storage.VirtualHostRecord
+		err := store.QueryVirtualHosts(ctx, filter, func(v *storage.VirtualHostRecord) error {
+			got = append(got, *v)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("QueryVirtualHosts failed: %v", err)
+		}
+		return got
+	}
+
+	all := collect(storage.VirtualHostFilter{IP: "10.6.0.1"})
+	if len(all) != 2 || all[0].Hostname != "creative.example.net" || all[1].Hostname != "survival.example.net" {
+		t.Fatalf("virtual hosts = %+v", all)
+	}
+	if all[0].Source != protocol.HostSourceSRV || all[0].IconHash != "abc" {
+		t.Errorf("creative = %+v", all[0])
+	}
+
+	got := collect(storage.VirtualHostFilter{Hostname: "SURVIVAL.example.net"})
+	if len(got) != 1 {
+		t.Fatalf("hostname filter = %+v", got)
+	}
+	v := got[0]
+	if v.IP != "10.6.0.1" || v.PlayersOnline != 30 || v.MOTD != "Survival" || v.VersionName != "Velocity 3.3.0" {
+		t.Errorf("survival = %+v", v)
+	}
+	if !v.FirstSeen.Equal(t1) || !v.LastSeen.Equal(t2) {
+		t.Errorf("first/last seen = %v/%v, want %v/%v", v.FirstSeen, v.LastSeen, t1, t2)
+	}
+
+	if got := collect(storage.VirtualHostFilter{IP: "10.6.0.2"}); len(got) != 0 {
+		t.Errorf("server without virtual hosts = %+v", got)
+	}
+}
@@ -0,0 +1,182 @@
+package vhost_test
+
+import (
+	"MinecraftCrawler/internal/mctest"
+	"MinecraftCrawler/internal/protocol"
+	"MinecraftCrawler/internal/vhost"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func statusWithMOTD(motd string) protocol.StatusResponse {
+	var status protocol.StatusResponse
+	status.Version.Name = "Velocity 3.3.0"
+	status.Version.Protocol = 765
+	status.Players.Online = 10
+	status.Players.Max = 500
+	status.Description = motd
+	return status
+}
+
+// fakeDNS answers from fixed tables, like a zone file.
+type fakeDNS struct {
+	ptr  map[string][]string
+	srv  map[string][]*net.SRV
+	host map[string][]string
+}
+
+func (d fakeDNS) LookupAddr(_ context.Context, addr string) ([]string, error) {
+	if names, ok := d.ptr[addr]; ok {
+		return names, nil
+	}
+	return nil, errors.New("no PTR")
+}
+
+func (d fakeDNS) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if records, ok := d.srv["_"+service+"._"+proto+"."+name]; ok {
+		return "", records, nil
+	}
+	return "", nil, errors.New("no SRV")
+}
+
+func (d fakeDNS) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addrs, ok := d.host[host]; ok {
+		return addrs, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func TestCandidates(t *testing.T) {
+	dns := fakeDNS{
+		ptr: map[string][]string{"10.0.0.1": {"node1.hosting.example.", "localhost"}},
+		srv: map[string][]*net.SRV{
+			"_minecraft._tcp.play.example.net": {
+				{Target: "other.example.net.", Port: 25566},
+				{Target: "mc.example.net.", Port: 25565},
+			},
+			"_minecraft._tcp.node1.hosting.example": {{Target: "elsewhere.example.", Port: 25565}},
+		},
+		host: map[string][]string{"mc.example.net": {"10.0.0.1"}, "elsewhere.example": {"10.9.9.9"}},
+	}
+	f := &vhost.Finder{
+		ReverseDNS: true,
+		SRV:        true,
+		Hosts:      map[string][]string{"10.0.0.1": {"play.example.net", "PLAY.example.net."}},
+		Resolver:   dns,
+	}
+
+	got := f.Candidates(context.Background(), "10.0.0.1", 25565)
+	want := []vhost.Candidate{
+		{Hostname: "play.example.net", Source: protocol.HostSourceList},
+		{Hostname: "mc.example.net", Source: protocol.HostSourceSRV},
+		{Hostname: "node1.hosting.example", Source: protocol.HostSourceReverseDNS},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Candidates = %+v, want %+v", got, want)
+	}
+
+	if got := f.Candidates(context.Background(), "10.0.0.2", 25565); len(got) != 0 {
+		t.Errorf("Candidates for an unknown IP = %+v", got)
+	}
+}
+
+// countingDNS counts SRV queries on top of fakeDNS.
+type countingDNS struct {
+	fakeDNS
+	srvLookups int
+}
+
+func (d *countingDNS) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	d.srvLookups++
+	return d.fakeDNS.LookupSRV(ctx, service, proto, name)
+}
+
+func TestCandidatesBoundsSRVLookups(t *testing.T) {
+	var ptr []string
+	for i := 0; i < 40; i++ {
+		ptr = append(ptr, fmt.Sprintf("host%d.example.net.", i), fmt.Sprintf("HOST%d.example.net", i))
+	}
+	dns := &countingDNS{fakeDNS: fakeDNS{ptr: map[string][]string{"10.0.0.1": ptr}}}
+	f := &vhost.Finder{ReverseDNS: true, SRV: true, Resolver: dns}
+
+	got := f.Candidates(context.Background(), "10.0.0.1", 25565)
+	if len(got) != 16 || dns.srvLookups != 16 {
+		t.Errorf("%d candidates after %d SRV lookups, want 16 and 16", len(got), dns.srvLookups)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dns.srvLookups = 0
+	f.Candidates(ctx, "10.0.0.1", 25565)
+	if dns.srvLookups != 0 {
+		t.Errorf("%d SRV lookups with a cancelled context", dns.srvLookups)
+	}
+}
+
+func TestLoadHostList(t *testing.T) {
+	dns := fakeDNS{host: map[string][]string{
+		"play.example.net": {"10.0.0.1", "::ffff:10.0.0.2"},
+		"mc.example.org":   {"10.0.0.1"},
+	}}
+	list := `# forced hosts
+play.example.net
+MC.example.org.   # same server
+unknown.example.com
+127.0.0.1
+`
+	hosts, err := vhost.LoadHostList(context.Background(), strings.NewReader(list), dns)
+	if err != nil {
+		t.Fatalf("LoadHostList failed: %v", err)
+	}
+	want := map[string][]string{
+		"10.0.0.1": {"play.example.net", "mc.example.org"},
+		"10.0.0.2": {"play.example.net"},
+	}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("LoadHostList = %v, want %v", hosts, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := vhost.LoadHostList(ctx, strings.NewReader(list), dns); !errors.Is(err, context.Canceled) {
+		t.Errorf("LoadHostList with a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestProbeKeepsDistinctVirtualHosts(t *testing.T) {
+	srv, err := mctest.Start(mctest.Config{
+		Status: statusWithMOTD("Lobby"),
+		HostStatus: map[string]interface{}{
+			"survival.example.net": statusWithMOTD("Survival"),
+			"www.example.net":      statusWithMOTD("Lobby"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("mctest.Start failed: %v", err)
+	}
+	t.Cleanup(srv.Close)
+
+	detail, err := protocol.AnalyzeServer(srv.Host, srv.Port, 2*time.Second)
+	if err != nil {
+		t.Fatalf("AnalyzeServer failed: %v", err)
+	}
+	f := &vhost.Finder{
+		Timeout: 2 * time.Second,
+		Hosts:   map[string][]string{srv.Host: {"www.example.net", "survival.example.net", "down.example.net"}},
+	}
+	hosts := f.Probe(context.Background(), detail)
+	if len(hosts) != 1 {
+		t.Fatalf("virtual hosts = %+v, want only survival.example.net", hosts)
+	}
+	vh := hosts[0]
+	if vh.Hostname != "survival.example.net" || vh.Source != protocol.HostSourceList || vh.MOTD != "Survival" ||
+		vh.VersionName != "Velocity 3.3.0" || vh.PlayersMax != 500 {
+		t.Errorf("virtual host = %+v", vh)
+	}
+}